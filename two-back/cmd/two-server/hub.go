@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+)
+
+type conn struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+// hub is the Notifier over the websocket connections of this process
+type hub struct {
+	mu    sync.Mutex
+	conns map[string]*conn
+}
+
+func newHub() *hub {
+	return &hub{conns: map[string]*conn{}}
+}
+
+func (h *hub) factory() ws.NotifierFactory {
+	return func(endpoint string) ws.Notifier {
+		return h
+	}
+}
+
+func (h *hub) register(connectionID string, c *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.conns[connectionID] = &conn{ws: c}
+}
+
+func (h *hub) unregister(connectionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.conns, connectionID)
+}
+
+func (h *hub) get(connectionID string) (*conn, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c, ok := h.conns[connectionID]
+	if !ok {
+		return nil, errors.New("connection is not exist")
+	}
+	return c, nil
+}
+
+// Send sends a message to the specified user
+func (h *hub) Send(connectionID string, message []byte) error {
+	c, err := h.get(connectionID)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ws.WriteMessage(websocket.TextMessage, message)
+}
+
+// Broadcast sends a message to the specified users
+func (h *hub) Broadcast(connectionIDs []string, message []byte) error {
+	return ws.Broadcast(h, connectionIDs, message)
+}
+
+// Disconnect closes the connection to the specified user.
+// The read loop of the connection runs the $disconnect route afterwards.
+func (h *hub) Disconnect(connectionID string) error {
+	c, err := h.get(connectionID)
+	if err != nil {
+		return err
+	}
+	return c.ws.Close()
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/daily"
+	"github.com/uu64/two-apps/two-back/lib/handler/join"
+	"github.com/uu64/two-apps/two-back/lib/handler/leave"
+	"github.com/uu64/two-apps/two-back/lib/handler/problem"
+	"github.com/uu64/two-apps/two-back/lib/handler/ready"
+	"github.com/uu64/two-apps/two-back/lib/handler/solve"
+	"github.com/uu64/two-apps/two-back/lib/handler/start"
+	"github.com/uu64/two-apps/two-back/lib/handler/timeout"
+	"github.com/uu64/two-apps/two-back/lib/matchmaker"
+	"github.com/uu64/two-apps/two-back/lib/rating"
+	"github.com/uu64/two-apps/two-back/lib/repository/players"
+	"github.com/uu64/two-apps/two-back/lib/repository/results"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+type request = events.APIGatewayWebsocketProxyRequest
+type response = events.APIGatewayProxyResponse
+
+// route is the handler of a route of the websocket api
+type route func(ctx context.Context, request request) (response, error)
+
+// stage is reported to the handlers as the stage of the websocket api
+var stage string = "local"
+
+type server struct {
+	hub      *hub
+	upgrader websocket.Upgrader
+	connect  route
+	leave    route
+	routes   map[string]route
+}
+
+// config is the settings given by the flags
+type config struct {
+	waitingTimeout time.Duration
+	readyTimeout   time.Duration
+	levelPolicy    game.LevelPolicy
+	filter         game.Filter
+	brackets       matchmaker.Brackets
+}
+
+func newServer(c config) *server {
+	hub := newHub()
+	roomStore := rooms.NewMemoryStore()
+	userStore := users.NewMemoryStore()
+	queue := matchmaker.NewMemoryMatchmaker()
+	queue.Brackets = c.brackets
+	rater := &rating.Rater{
+		Players: players.NewMemoryStore(),
+		Users:   userStore,
+	}
+
+	timeoutHandler := &timeout.Handler{
+		Rooms:    roomStore,
+		Notifier: hub.factory(),
+		Rater:    rater,
+	}
+	timers := scheduler.NewTimerScheduler(func(job scheduler.Job) {
+		err := timeoutHandler.Run(context.Background(), job)
+		if err != nil {
+			log.Println(err)
+		}
+	})
+	startHandler := &start.Handler{
+		Rooms:        roomStore,
+		Notifier:     hub.factory(),
+		Scheduler:    timers,
+		ReadyTimeout: c.readyTimeout,
+	}
+	joinHandler := &join.Handler{
+		Rooms:      roomStore,
+		Users:      userStore,
+		Matchmaker: queue,
+		// the connection is registered to the hub before $connect,
+		// so the users can be told right away
+		Announcer: matchmaker.AnnouncerFunc(func(a matchmaker.Announcement) error {
+			return startHandler.Start(context.Background(), a)
+		}),
+		Scheduler:      timers,
+		WaitingTimeout: c.waitingTimeout,
+	}
+	timeoutHandler.Lobby = joinHandler
+	leaveHandler := &leave.Handler{
+		Rooms:      roomStore,
+		Users:      userStore,
+		Matchmaker: queue,
+		Notifier:   hub.factory(),
+		Rater:      rater,
+	}
+	readyHandler := &ready.Handler{
+		Rooms:       roomStore,
+		Users:       userStore,
+		Notifier:    hub.factory(),
+		Scheduler:   timers,
+		LevelPolicy: c.levelPolicy,
+		Filter:      &c.filter,
+	}
+	problemHandler := &problem.Handler{
+		Rooms:    roomStore,
+		Users:    userStore,
+		Notifier: hub.factory(),
+	}
+	solveHandler := &solve.Handler{
+		Rooms:    roomStore,
+		Users:    userStore,
+		Notifier: hub.factory(),
+		Rater:    rater,
+	}
+	dailyHandler := &daily.Handler{
+		Results:  results.NewMemoryStore(),
+		Users:    userStore,
+		Notifier: hub.factory(),
+	}
+
+	return &server{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			// the frontend is served from another origin
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		connect: joinHandler.Handle,
+		leave:   leaveHandler.Handle,
+		routes: map[string]route{
+			"daily":   dailyHandler.Handle,
+			"problem": problemHandler.Handle,
+			"ready":   readyHandler.Handle,
+			"solve":   solveHandler.Handle,
+		},
+	}
+}
+
+func newRequest(r *http.Request, connectionID string, routeKey string, body string) request {
+	query := map[string]string{}
+	for k, v := range r.URL.Query() {
+		query[k] = v[0]
+	}
+
+	return request{
+		Body:                  body,
+		QueryStringParameters: query,
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			ConnectionID: connectionID,
+			RouteKey:     routeKey,
+			DomainName:   r.Host,
+			Stage:        stage,
+		},
+	}
+}
+
+// routeKey selects the route like $request.body.action of api gateway
+func routeKey(body []byte) string {
+	var selection struct {
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(body, &selection); err != nil {
+		return ""
+	}
+	return selection.Action
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer c.Close()
+
+	connectionID := uuid.New().String()
+	s.hub.register(connectionID, c)
+
+	ctx := r.Context()
+	res, err := s.connect(ctx, newRequest(r, connectionID, "$connect", ""))
+	if err != nil {
+		log.Println(err)
+	}
+	if err != nil || res.StatusCode != 200 {
+		// API Gateway refuses the connection unless $connect returns 200
+		s.hub.unregister(connectionID)
+		return
+	}
+
+	for {
+		_, body, err := c.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		key := routeKey(body)
+		route, ok := s.routes[key]
+		if !ok {
+			log.Printf("route %q is not found\n", key)
+			continue
+		}
+
+		_, err = route(ctx, newRequest(r, connectionID, key, string(body)))
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	s.hub.unregister(connectionID)
+	_, err = s.leave(context.Background(), newRequest(r, connectionID, "$disconnect", ""))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// serveRejections writes the number of the rejected problems by the reason
+func serveRejections(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(game.Rejected.Counts())
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	waitingTimeout := flag.Duration("waiting-timeout", join.DefaultWaitingTimeout,
+		"how long a room waits for a challenger")
+	readyTimeout := flag.Duration("ready-timeout", start.DefaultReadyTimeout,
+		"how long the matched users have to get ready")
+	policyName := flag.String("level-policy", string(ready.DefaultLevelPolicy),
+		"how the level is chosen from the levels the users prefer: MIN, AVERAGE or CREATOR")
+	brackets := flag.String("brackets", "3,6,10",
+		"highest levels of the matchmaking brackets, separated by commas")
+	crossAfter := flag.Duration("cross-after", matchmaker.DefaultBrackets.CrossAfter,
+		"how long a room waits until it accepts any level, 0 to never")
+	noZeroTerms := flag.Bool("no-zero-terms", game.DefaultFilter.NoZeroTerms,
+		"reject the problems with a zero term")
+	maxSolutions := flag.Int("max-solutions", game.DefaultFilter.MaxSolutions,
+		"reject the problems with more answers, 0 to never")
+	requireMinus := flag.Bool("require-minus", game.DefaultFilter.RequireMinus,
+		"reject the problems solved with plus only")
+	flag.Parse()
+
+	levelPolicy, err := game.ParseLevelPolicy(*policyName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bounds, err := matchmaker.ParseBounds(*brackets)
+	if err != nil {
+		log.Fatal(err)
+	}
+	filter := game.Filter{
+		NoZeroTerms:  *noZeroTerms,
+		MaxSolutions: *maxSolutions,
+		RequireMinus: *requireMinus,
+	}
+	// a level without problems would leave its rooms unable to start
+	err = game.CheckFilter(filter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", newServer(config{
+		waitingTimeout: *waitingTimeout,
+		readyTimeout:   *readyTimeout,
+		levelPolicy:    levelPolicy,
+		filter:         filter,
+		brackets:       matchmaker.Brackets{Bounds: bounds, CrossAfter: *crossAfter},
+	}))
+	mux.HandleFunc("/debug/rejections", serveRejections)
+
+	fmt.Printf("listening on ws://%s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
@@ -7,4 +7,5 @@ require (
 	github.com/aws/aws-sdk-go v1.34.22
 	github.com/aws/aws-sdk-go-v2 v0.24.0 // indirect
 	github.com/google/uuid v1.1.2
+	github.com/gorilla/websocket v1.4.2
 )
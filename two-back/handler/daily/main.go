@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/uu64/two-apps/two-back/lib/handler/daily"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/repository/results"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+)
+
+var h *daily.Handler
+
+func init() {
+	session := session.New()
+	dynamoSvc := dynamodb.New(session)
+	h = &daily.Handler{
+		Results:  results.NewDynamoStore(dynamoSvc),
+		Users:    users.NewDynamoStore(dynamoSvc),
+		Notifier: ws.NewAPIGatewayFactory(session),
+	}
+}
+
+func main() {
+	lambda.Start(h.Handle)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/uu64/two-apps/two-back/lib/config"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/ready"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+var h *ready.Handler
+
+func init() {
+	session := session.New()
+	dynamoSvc := dynamodb.New(session)
+	levelPolicy, err := game.ParseLevelPolicy(
+		config.String("LEVEL_POLICY", string(ready.DefaultLevelPolicy)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	h = &ready.Handler{
+		Rooms:       rooms.NewDynamoStore(dynamoSvc),
+		Users:       users.NewDynamoStore(dynamoSvc),
+		Notifier:    ws.NewAPIGatewayFactory(session),
+		Scheduler:   scheduler.NewSQSScheduler(sqs.New(session), "timeouts"),
+		LevelPolicy: levelPolicy,
+	}
+}
+
+func main() {
+	lambda.Start(h.Handle)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/uu64/two-apps/two-back/lib/config"
+	"github.com/uu64/two-apps/two-back/lib/handler/start"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+var h *start.Handler
+
+func init() {
+	session := session.New()
+	dynamoSvc := dynamodb.New(session)
+	readyTimeout, err := config.Duration("READY_TIMEOUT", start.DefaultReadyTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	h = &start.Handler{
+		Rooms:        rooms.NewDynamoStore(dynamoSvc),
+		Notifier:     ws.NewAPIGatewayFactory(session),
+		Scheduler:    scheduler.NewSQSScheduler(sqs.New(session), "timeouts"),
+		ReadyTimeout: readyTimeout,
+	}
+}
+
+func main() {
+	lambda.Start(h.HandleSQS)
+}
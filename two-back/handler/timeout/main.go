@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/uu64/two-apps/two-back/lib/config"
+	"github.com/uu64/two-apps/two-back/lib/handler/join"
+	"github.com/uu64/two-apps/two-back/lib/handler/timeout"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/matchmaker"
+	"github.com/uu64/two-apps/two-back/lib/rating"
+	"github.com/uu64/two-apps/two-back/lib/repository/players"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+var h *timeout.Handler
+
+func init() {
+	session := session.New()
+	dynamoSvc := dynamodb.New(session)
+	sqsSvc := sqs.New(session)
+	roomStore := rooms.NewDynamoStore(dynamoSvc)
+	userStore := users.NewDynamoStore(dynamoSvc)
+	// the ready users are matched again like a new connection
+	waitingTimeout, err := config.Duration("WAITING_TIMEOUT", join.DefaultWaitingTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	brackets, err := config.Brackets()
+	if err != nil {
+		log.Fatal(err)
+	}
+	queue := matchmaker.NewSQSMatchmaker(sqsSvc, "matching")
+	queue.Brackets = brackets
+	h = &timeout.Handler{
+		Rooms:    roomStore,
+		Notifier: ws.NewAPIGatewayFactory(session),
+		Rater: &rating.Rater{
+			Players: players.NewDynamoStore(dynamoSvc),
+			Users:   userStore,
+		},
+		Lobby: &join.Handler{
+			Rooms:          roomStore,
+			Users:          userStore,
+			Matchmaker:     queue,
+			Announcer:      matchmaker.NewSQSAnnouncer(sqsSvc, "matched"),
+			Scheduler:      scheduler.NewSQSScheduler(sqsSvc, "timeouts"),
+			WaitingTimeout: waitingTimeout,
+		},
+	}
+}
+
+func main() {
+	lambda.Start(h.HandleSQS)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"time"
+
+	"github.com/uu64/two-apps/two-back/lib/matchmaker"
+)
+
+// String returns the environment variable, or fallback when it is not set
+func String(name string, fallback string) string {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Duration returns the duration in the environment variable like "30s",
+// or fallback when it is not set
+func Duration(name string, fallback time.Duration) (time.Duration, error) {
+	value := String(name, "")
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// Brackets returns the brackets of the matchmaking
+// from MATCH_BRACKETS like "3,6,10" and MATCH_CROSS_AFTER like "30s",
+// or matchmaker.DefaultBrackets for the one not set
+func Brackets() (matchmaker.Brackets, error) {
+	brackets := matchmaker.DefaultBrackets
+
+	if value := String("MATCH_BRACKETS", ""); value != "" {
+		bounds, err := matchmaker.ParseBounds(value)
+		if err != nil {
+			return brackets, err
+		}
+		brackets.Bounds = bounds
+	}
+
+	crossAfter, err := Duration("MATCH_CROSS_AFTER", brackets.CrossAfter)
+	if err != nil {
+		return brackets, err
+	}
+	brackets.CrossAfter = crossAfter
+	return brackets, nil
+}
@@ -0,0 +1,43 @@
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrAlreadySolved is returned when the daily challenge has been solved
+var ErrAlreadySolved = errors.New("ALREADY_SOLVED")
+
+// DailyLevel is the level of the problem of the daily challenge
+var DailyLevel int = 5
+
+// dailyLayout is the layout of the date of the daily challenge
+const dailyLayout = "2006-01-02"
+
+// DailyDate returns the calendar day of the time in UTC
+func DailyDate(t time.Time) string {
+	return t.UTC().Format(dailyLayout)
+}
+
+// ParseDailyDate returns the time the day of the daily challenge starts at
+func ParseDailyDate(date string) (time.Time, error) {
+	return time.Parse(dailyLayout, date)
+}
+
+// DailySeed returns the seed of the problem of the day, like 20201231
+func DailySeed(t time.Time) int64 {
+	year, month, day := t.UTC().Date()
+	return int64(year*10000 + int(month)*100 + day)
+}
+
+// DailyProblem returns the problem of the day every player gets, and its spec
+func DailyProblem(t time.Time) (Spec, []int, error) {
+	spec, err := SpecForLevel(DailyLevel)
+	if err != nil {
+		return Spec{}, nil, err
+	}
+	// the same problem is created again on every request,
+	// so its rejections are kept out of Rejected
+	problem, err := createProblem(spec, DailySeed(t), &Rejections{})
+	return spec, problem, err
+}
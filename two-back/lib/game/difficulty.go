@@ -0,0 +1,132 @@
+package game
+
+import (
+	"math"
+
+	"github.com/uu64/two-apps/two-back/lib/solver"
+)
+
+// Features are the measurable properties of a problem which make it hard
+type Features struct {
+	// Solutions is the number of the answers
+	Solutions int
+	// Magnitude is the mean absolute value of the terms
+	// relative to the largest term the spec allows, from 0 to 1
+	Magnitude float64
+	// MinusSigns is the fewest minus signs an answer needs
+	MinusSigns int
+	// ZeroTerms is the number of the terms which are 0
+	ZeroTerms int
+	// Terms is the number of the terms
+	Terms int
+}
+
+// Band is the range of the difficulty score, both ends included
+type Band struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Contains returns whether the score is in the band.
+// The zero band contains every score.
+func (b Band) Contains(score float64) bool {
+	return b.distance(score) == 0
+}
+
+// distance returns how far the score is from the band
+func (b Band) distance(score float64) float64 {
+	if b == (Band{}) {
+		return 0
+	}
+	if score < b.Min {
+		return b.Min - score
+	}
+	if score > b.Max {
+		return score - b.Max
+	}
+	return 0
+}
+
+// BandWidth is how far the band of a level spreads around its center,
+// in proportion to the center
+var BandWidth float64 = 0.2
+
+// MinBandWidth is the least the band spreads around its center,
+// since the scores of the short problems are few and far apart
+var MinBandWidth float64 = 0.5
+
+// BandForLevel returns the band of the difficulty of the level.
+// The center of the band is level-1, which is the score of an average problem.
+func BandForLevel(level int) Band {
+	center := float64(level - 1)
+	width := center * BandWidth
+	if width < MinBandWidth {
+		width = MinBandWidth
+	}
+	return Band{Min: center - width, Max: center + width}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Analyze measures the features of the problem
+func Analyze(spec Spec, problem []int) Features {
+	f := Features{Terms: len(problem)}
+
+	largest := abs(spec.MaxTerm)
+	if abs(spec.MinTerm) > largest {
+		largest = abs(spec.MinTerm)
+	}
+	if largest == 0 {
+		largest = 1
+	}
+
+	sum := 0
+	for _, term := range problem {
+		sum += abs(term)
+		if term == 0 {
+			f.ZeroTerms++
+		}
+	}
+	if len(problem) > 0 {
+		f.Magnitude = float64(sum) / float64(len(problem)*largest)
+		if f.Magnitude > 1 {
+			f.Magnitude = 1
+		}
+	}
+
+	f.Solutions = solver.Count(spec.Problem(problem))
+	f.MinusSigns, _ = solver.Fewest(spec.Problem(problem), OpMinus)
+
+	return f
+}
+
+// Score returns the difficulty of the problem.
+// The base is the bits to find an answer among every choice of the operators,
+// which is weighted from 0.5 to 1.5 by the size of the terms,
+// the minus signs and the zero terms.
+func (f Features) Score(operators int) float64 {
+	if f.Solutions == 0 || f.Terms < 2 || operators < 2 {
+		return 0
+	}
+
+	slots := float64(f.Terms - 1)
+	bits := slots*math.Log2(float64(operators)) - math.Log2(float64(f.Solutions))
+
+	weight := 0.75 +
+		0.25*f.Magnitude +
+		0.5*float64(f.MinusSigns)/slots -
+		0.5*float64(f.ZeroTerms)/float64(f.Terms)
+	if weight < 0.5 {
+		weight = 0.5
+	}
+	if weight > 1.5 {
+		weight = 1.5
+	}
+
+	return bits * weight
+}
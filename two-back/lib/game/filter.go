@@ -0,0 +1,120 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+)
+
+// reasons the problems are rejected for
+const (
+	RejectNegative         = "NEGATIVE_TERM"
+	RejectZeroTerm         = "ZERO_TERM"
+	RejectUnsolvable       = "UNSOLVABLE"
+	RejectTooManySolutions = "TOO_MANY_SOLUTIONS"
+	RejectNoMinus          = "NO_MINUS"
+	RejectOutOfBand        = "OUT_OF_BAND"
+)
+
+// Filter is the rules rejecting the degenerate problems
+type Filter struct {
+	// NoZeroTerms rejects the problems with a zero term,
+	// which makes the operator before it irrelevant
+	NoZeroTerms bool `json:"noZeroTerms"`
+	// MaxSolutions rejects the problems with more answers, or none when 0
+	MaxSolutions int `json:"maxSolutions"`
+	// RequireMinus rejects the problems which "all plus" solves
+	RequireMinus bool `json:"requireMinus"`
+}
+
+// DefaultFilter is the filter of every level
+var DefaultFilter = Filter{
+	NoZeroTerms:  true,
+	RequireMinus: true,
+}
+
+// filterCheckSeeds are the seeds CheckFilter creates the problems from
+var filterCheckSeeds = []int64{1, 2, 3}
+
+// CheckFilter returns an error naming the first level
+// where the filter rejects every problem created from a few seeds
+func CheckFilter(f Filter) error {
+	for level := 1; ValidateLevel(level) == nil; level++ {
+		spec, err := SpecForLevel(level)
+		if err != nil {
+			return err
+		}
+		spec = spec.WithFilter(f)
+
+		err = ErrNoProblem
+		for _, seed := range filterCheckSeeds {
+			// the trials are not counted as rejected
+			_, err = createProblem(spec, seed, &Rejections{})
+			if err != ErrNoProblem {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("level %d: %w", level, err)
+		}
+	}
+	return nil
+}
+
+// Rejections counts the rejected problems by the reason
+type Rejections struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// Rejected is the count of the problems rejected by CreateProblem
+var Rejected = &Rejections{}
+
+func (r *Rejections) add(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.counts == nil {
+		r.counts = map[string]int{}
+	}
+	r.counts[reason]++
+}
+
+// Counts returns the number of the rejected problems by the reason
+func (r *Rejections) Counts() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := map[string]int{}
+	for reason, n := range r.counts {
+		counts[reason] = n
+	}
+	return counts
+}
+
+// check returns the features of the problem,
+// or the reason it is rejected for by the spec
+func check(spec Spec, terms []int) (Features, string) {
+	if !spec.Negatives && terms[0] < 0 {
+		return Features{}, RejectNegative
+	}
+	if spec.Filter.NoZeroTerms {
+		for _, term := range terms {
+			if term == 0 {
+				return Features{}, RejectZeroTerm
+			}
+		}
+	}
+
+	// every problem is confirmed, whichever way it is made
+	features := Analyze(spec, terms)
+	if features.Solutions == 0 {
+		return features, RejectUnsolvable
+	}
+	if spec.Filter.MaxSolutions > 0 && features.Solutions > spec.Filter.MaxSolutions {
+		return features, RejectTooManySolutions
+	}
+	if spec.Filter.RequireMinus && features.MinusSigns == 0 {
+		return features, RejectNoMinus
+	}
+	return features, ""
+}
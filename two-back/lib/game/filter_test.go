@@ -0,0 +1,43 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		wantErr error
+	}{
+		{"default", DefaultFilter, nil},
+		{"none", Filter{}, nil},
+		{"too few answers for the long levels", Filter{MaxSolutions: 3}, ErrNoProblem},
+		{"negative answers", Filter{MaxSolutions: -1}, ErrInvalidSpec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckFilter(tt.filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckFilter(%+v) = %v, want %v", tt.filter, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDailyProblemNotRejected(t *testing.T) {
+	before := Rejected.Counts()
+	for day := 0; day < 30; day++ {
+		if _, _, err := DailyProblem(time.Unix(0, 0).AddDate(0, 0, day)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := Rejected.Counts(); !reflect.DeepEqual(got, before) {
+		t.Errorf("rejected = %v after the daily problems, want %v", got, before)
+	}
+}
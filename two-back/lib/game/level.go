@@ -0,0 +1,100 @@
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+// LevelPolicy decides the level of the match from the levels the players prefer
+type LevelPolicy string
+
+const (
+	// LevelPolicyMin chooses the easier level
+	LevelPolicyMin LevelPolicy = "MIN"
+	// LevelPolicyAverage chooses the rounded average of the levels
+	LevelPolicyAverage LevelPolicy = "AVERAGE"
+	// LevelPolicyCreator chooses the level of the player who created the room
+	LevelPolicyCreator LevelPolicy = "CREATOR"
+)
+
+// TimeLimitBase is the time limit of every level
+var TimeLimitBase time.Duration = 20 * time.Second
+
+// TimeLimitPerTerm is the time added to the limit for each term of the problem
+var TimeLimitPerTerm time.Duration = 10 * time.Second
+
+// TimeLimit returns how long the game of the level lasts
+func TimeLimit(level int) time.Duration {
+	return TimeLimitBase + time.Duration(level)*TimeLimitPerTerm
+}
+
+// ErrUnknownLevelPolicy is returned when the policy is not defined
+var ErrUnknownLevelPolicy = errors.New("unknown level policy")
+
+// ValidateLevel returns ErrInvalidLevel when no problem can be created at the level
+func ValidateLevel(level int) error {
+	if level > 10 || level < 0 {
+		return ErrInvalidLevel
+	}
+	return nil
+}
+
+// MinMatchLevel is the lowest level of the matches.
+// The problem of a single term has no operator to choose,
+// so the player answering nothing would win it for sure.
+var MinMatchLevel int = 2
+
+// ValidateMatchLevel returns ErrInvalidLevel when the players cannot compete at the level.
+// 0 means no preference.
+func ValidateMatchLevel(level int) error {
+	if level != 0 && level < MinMatchLevel {
+		return ErrInvalidLevel
+	}
+	return ValidateLevel(level)
+}
+
+// ParseLevelPolicy returns the policy with the name
+func ParseLevelPolicy(name string) (LevelPolicy, error) {
+	policy := LevelPolicy(name)
+	switch policy {
+	case LevelPolicyMin, LevelPolicyAverage, LevelPolicyCreator:
+		return policy, nil
+	}
+	return policy, ErrUnknownLevelPolicy
+}
+
+// Resolve returns the level of the match.
+// levels are the levels the players prefer in the order of the players,
+// where the room creator comes first and 0 means no preference.
+// fallback is returned when nobody has a preference.
+func (p LevelPolicy) Resolve(levels []int, fallback int) int {
+	var preferred []int
+	for _, level := range levels {
+		if level > 0 {
+			preferred = append(preferred, level)
+		}
+	}
+	if len(preferred) == 0 {
+		return fallback
+	}
+
+	switch p {
+	case LevelPolicyAverage:
+		sum := 0
+		for _, level := range preferred {
+			sum += level
+		}
+		return (sum*2 + len(preferred)) / (len(preferred) * 2)
+	case LevelPolicyCreator:
+		// the other player's level is used when the creator has no preference
+		return preferred[0]
+	}
+
+	level := preferred[0]
+	for _, l := range preferred[1:] {
+		if l < level {
+			level = l
+		}
+	}
+	return level
+}
@@ -0,0 +1,53 @@
+package game
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   LevelPolicy
+		levels   []int
+		fallback int
+		want     int
+	}{
+		{"min", LevelPolicyMin, []int{5, 3}, 1, 3},
+		{"min ignores no preference", LevelPolicyMin, []int{0, 4}, 1, 4},
+		{"average", LevelPolicyAverage, []int{2, 4}, 1, 3},
+		{"average rounds half up", LevelPolicyAverage, []int{2, 3}, 1, 3},
+		{"average rounds down", LevelPolicyAverage, []int{1, 2, 2}, 1, 2},
+		{"average ignores no preference", LevelPolicyAverage, []int{0, 7}, 1, 7},
+		{"creator", LevelPolicyCreator, []int{6, 2}, 1, 6},
+		{"creator without preference", LevelPolicyCreator, []int{0, 2}, 1, 2},
+		{"nobody has a preference", LevelPolicyCreator, []int{0, 0}, 4, 4},
+		{"no players", LevelPolicyMin, nil, 4, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.Resolve(tt.levels, tt.fallback)
+			if got != tt.want {
+				t.Errorf("%s.Resolve(%v, %d) = %d, want %d", tt.policy, tt.levels, tt.fallback, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMatchLevel(t *testing.T) {
+	tests := []struct {
+		level   int
+		wantErr error
+	}{
+		{0, nil},
+		{1, ErrInvalidLevel},
+		{2, nil},
+		{10, nil},
+		{11, ErrInvalidLevel},
+		{-1, ErrInvalidLevel},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateMatchLevel(tt.level); err != tt.wantErr {
+			t.Errorf("ValidateMatchLevel(%d) = %v, want %v", tt.level, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnknownPlayer is returned when the player is not in the match
+var ErrUnknownPlayer = errors.New("USER_NOT_FOUND")
+
+// ErrNotStarted is returned when the game has not started yet
+var ErrNotStarted = errors.New("GAME_NOT_STARTED")
+
+// ErrAlreadyStarted is returned when the game has already started
+var ErrAlreadyStarted = errors.New("GAME_ALREADY_STARTED")
+
+// ErrGameOver is returned when the game has already ended
+var ErrGameOver = errors.New("GAME_OVER")
+
+// ReasonSolved is the reason of the match won by the correct answer
+const ReasonSolved string = "SOLVED"
+
+// ReasonForfeit is the reason of the match won because the opponent left
+const ReasonForfeit string = "FORFEIT"
+
+// ReasonTimeUp is the reason of the match nobody solved in time
+const ReasonTimeUp string = "TIME_UP"
+
+// Event is a message to send to the players
+type Event struct {
+	To      []string `json:"-"`
+	Message string   `json:"message"`
+	Problem []int    `json:"problem,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+	// Spec is what the problem is like
+	Spec *Spec `json:"spec,omitempty"`
+	// Level is the level the problem is created at
+	Level int `json:"level,omitempty"`
+	// TimeLimit is the seconds the players have to solve the problem
+	TimeLimit int `json:"timeLimit,omitempty"`
+	// ReadyTimeout is the seconds the players have to get ready
+	ReadyTimeout int `json:"readyTimeout,omitempty"`
+	// Date is the day of the daily challenge
+	Date string `json:"date,omitempty"`
+	// Rank is the place of the player in the daily challenge
+	Rank int `json:"rank,omitempty"`
+	// SolveTime is the milliseconds the player took to solve the daily challenge
+	SolveTime int64 `json:"solveTime,omitempty"`
+	// Rating is the rating of the player after the match
+	Rating int `json:"rating,omitempty"`
+	// RatingChange is how much the match has changed the rating
+	RatingChange int `json:"ratingChange,omitempty"`
+}
+
+// Submission is an answer a player submitted
+type Submission struct {
+	Player  string
+	Answer  []string
+	Correct bool
+}
+
+// Match is a game between two players
+type Match struct {
+	Players     []string
+	Problem     []int
+	Seed        int64
+	Spec        Spec
+	TimeLimit   int
+	Submissions []Submission
+	Winner      string
+	// Reason is why the match has ended
+	Reason string
+	// Started is true once the problem has been given
+	Started bool
+	// StartedAt is when the problem has been given
+	StartedAt time.Time
+	// Over is true once the game can no longer be played
+	Over bool
+}
+
+// NewMatch returns the match between the players
+func NewMatch(player1 string, player2 string) *Match {
+	return &Match{Players: []string{player1, player2}}
+}
+
+// Opponent returns the other player of the match
+func (m *Match) Opponent(player string) (string, error) {
+	if m.Players[0] == player {
+		return m.Players[1], nil
+	} else if m.Players[1] == player {
+		return m.Players[0], nil
+	}
+	return "", ErrUnknownPlayer
+}
+
+// Deadline returns when the time limit of the game passes
+func (m *Match) Deadline() time.Time {
+	return m.StartedAt.Add(time.Duration(m.TimeLimit) * time.Second)
+}
+
+// Start gives a problem of the level the filter accepts to both players at now
+func (m *Match) Start(level int, filter Filter, now time.Time) ([]Event, error) {
+	if m.Over {
+		return nil, ErrGameOver
+	}
+	if m.Started {
+		return nil, ErrAlreadyStarted
+	}
+
+	spec, err := SpecForLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	spec = spec.WithFilter(filter)
+	seed := NewSeed()
+	problem, err := CreateProblem(spec, seed)
+	if err != nil {
+		return nil, err
+	}
+	m.Problem = problem
+	m.Seed = seed
+	m.Spec = spec
+	m.TimeLimit = int(TimeLimit(level) / time.Second)
+	m.Started = true
+	m.StartedAt = now
+
+	return []Event{
+		{
+			To:        m.Players,
+			Message:   "START_GAME",
+			Problem:   problem,
+			Spec:      &spec,
+			Level:     level,
+			TimeLimit: m.TimeLimit,
+		},
+	}, nil
+}
+
+// Submit judges the answer of the player submitted at now.
+// The first correct answer wins the match,
+// and no answer is accepted after the deadline.
+func (m *Match) Submit(player string, answer []string, now time.Time) ([]Event, error) {
+	opponent, err := m.Opponent(player)
+	if err != nil {
+		return nil, err
+	}
+	if m.Over || m.Winner != "" {
+		return nil, ErrGameOver
+	}
+	if !m.Started {
+		return nil, ErrNotStarted
+	}
+	if !now.Before(m.Deadline()) {
+		// the time up job has not ended the game yet
+		return nil, ErrGameOver
+	}
+
+	correct := CheckAnswer(m.Spec, m.Problem, answer)
+	m.Submissions = append(m.Submissions, Submission{
+		Player:  player,
+		Answer:  answer,
+		Correct: correct,
+	})
+	if !correct {
+		return []Event{
+			{To: []string{player}, Message: "WRONG_ANSWER"},
+		}, nil
+	}
+
+	m.Winner = player
+	m.Reason = ReasonSolved
+	m.Over = true
+	return []Event{
+		{To: []string{player}, Message: "YOU_WIN", Reason: ReasonSolved},
+		{To: []string{opponent}, Message: "YOU_LOSE", Reason: ReasonSolved},
+	}, nil
+}
+
+// Forfeit gives up the match of the player at now.
+// The opponent wins if the game has started,
+// and the match is a draw by time up after the deadline.
+func (m *Match) Forfeit(player string, now time.Time) ([]Event, error) {
+	opponent, err := m.Opponent(player)
+	if err != nil {
+		return nil, err
+	}
+	if m.Over || m.Winner != "" {
+		return nil, ErrGameOver
+	}
+	if m.Started && !now.Before(m.Deadline()) {
+		// the time up job has not ended the game yet
+		return m.TimeUp()
+	}
+
+	m.Over = true
+	events := []Event{
+		{To: []string{opponent}, Message: "OPPONENT_LEFT"},
+	}
+	if !m.Started {
+		return events, nil
+	}
+
+	m.Winner = opponent
+	m.Reason = ReasonForfeit
+	return append(events, Event{
+		To: []string{opponent}, Message: "YOU_WIN", Reason: ReasonForfeit,
+	}), nil
+}
+
+// TimeUp ends the match nobody has solved as a draw
+func (m *Match) TimeUp() ([]Event, error) {
+	if m.Over || m.Winner != "" {
+		return nil, ErrGameOver
+	}
+	if !m.Started {
+		return nil, ErrNotStarted
+	}
+
+	m.Reason = ReasonTimeUp
+	m.Over = true
+	return []Event{
+		{To: m.Players, Message: "TIME_UP", Reason: ReasonTimeUp},
+	}, nil
+}
@@ -0,0 +1,276 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// startedMatch returns the match of alice and bob playing 3 ? 2 ? 1
+func startedMatch() *Match {
+	m := NewMatch("alice", "bob")
+	m.Problem = []int{3, 2, 1}
+	m.Spec = DefaultSpec
+	m.Spec.Terms = 3
+	m.TimeLimit = 60
+	m.Started = true
+	m.StartedAt = time.Unix(0, 0)
+	return m
+}
+
+// messages returns the message to each player of the events
+func messages(events []Event) map[string][]string {
+	got := map[string][]string{}
+	for _, e := range events {
+		for _, to := range e.To {
+			got[to] = append(got[to], e.Message)
+		}
+	}
+	return got
+}
+
+func TestSubmit(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   func() *Match
+		player  string
+		answer  []string
+		elapsed time.Duration
+		want    map[string][]string
+		winner  string
+		wantErr error
+	}{
+		{
+			name:   "correct",
+			match:  startedMatch,
+			player: "alice",
+			answer: []string{OpMinus, OpPlus},
+			want:   map[string][]string{"alice": {"YOU_WIN"}, "bob": {"YOU_LOSE"}},
+			winner: "alice",
+		},
+		{
+			name:   "wrong",
+			match:  startedMatch,
+			player: "bob",
+			answer: []string{OpPlus, OpPlus},
+			want:   map[string][]string{"bob": {"WRONG_ANSWER"}},
+		},
+		{
+			name:   "operator not allowed",
+			match:  startedMatch,
+			player: "bob",
+			answer: []string{OpMinus, OpTimes},
+			want:   map[string][]string{"bob": {"WRONG_ANSWER"}},
+		},
+		{
+			name:    "just before the time limit",
+			match:   startedMatch,
+			player:  "alice",
+			answer:  []string{OpMinus, OpPlus},
+			elapsed: 59 * time.Second,
+			want:    map[string][]string{"alice": {"YOU_WIN"}, "bob": {"YOU_LOSE"}},
+			winner:  "alice",
+		},
+		{
+			name:    "after the time limit",
+			match:   startedMatch,
+			player:  "alice",
+			answer:  []string{OpMinus, OpPlus},
+			elapsed: 60 * time.Second,
+			wantErr: ErrGameOver,
+		},
+		{
+			name: "not started",
+			match: func() *Match {
+				return NewMatch("alice", "bob")
+			},
+			player:  "alice",
+			answer:  []string{OpMinus, OpPlus},
+			wantErr: ErrNotStarted,
+		},
+		{
+			name: "over",
+			match: func() *Match {
+				m := startedMatch()
+				m.Winner = "bob"
+				m.Over = true
+				return m
+			},
+			player:  "alice",
+			answer:  []string{OpMinus, OpPlus},
+			wantErr: ErrGameOver,
+		},
+		{
+			name:    "unknown player",
+			match:   startedMatch,
+			player:  "carol",
+			answer:  []string{OpMinus, OpPlus},
+			wantErr: ErrUnknownPlayer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.match()
+			events, err := m.Submit(tt.player, tt.answer, m.StartedAt.Add(tt.elapsed))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := messages(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+			if m.Winner != tt.winner {
+				t.Errorf("winner = %q, want %q", m.Winner, tt.winner)
+			}
+		})
+	}
+}
+
+func TestForfeit(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   func() *Match
+		elapsed time.Duration
+		want    map[string][]string
+		winner  string
+		wantErr error
+	}{
+		{
+			name: "before start",
+			match: func() *Match {
+				return NewMatch("alice", "bob")
+			},
+			want: map[string][]string{"bob": {"OPPONENT_LEFT"}},
+		},
+		{
+			name:   "after start",
+			match:  startedMatch,
+			want:   map[string][]string{"bob": {"OPPONENT_LEFT", "YOU_WIN"}},
+			winner: "bob",
+		},
+		{
+			name:    "after the time limit",
+			match:   startedMatch,
+			elapsed: 60 * time.Second,
+			want:    map[string][]string{"alice": {"TIME_UP"}, "bob": {"TIME_UP"}},
+		},
+		{
+			name: "over",
+			match: func() *Match {
+				m := startedMatch()
+				m.Reason = ReasonTimeUp
+				m.Over = true
+				return m
+			},
+			wantErr: ErrGameOver,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.match()
+			events, err := m.Forfeit("alice", m.StartedAt.Add(tt.elapsed))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := messages(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+			if m.Winner != tt.winner || !m.Over {
+				t.Errorf("winner = %q over = %v, want %q over", m.Winner, m.Over, tt.winner)
+			}
+		})
+	}
+}
+
+func TestTimeUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   func() *Match
+		want    map[string][]string
+		wantErr error
+	}{
+		{
+			name:  "playing",
+			match: startedMatch,
+			want:  map[string][]string{"alice": {"TIME_UP"}, "bob": {"TIME_UP"}},
+		},
+		{
+			name: "not started",
+			match: func() *Match {
+				return NewMatch("alice", "bob")
+			},
+			wantErr: ErrNotStarted,
+		},
+		{
+			name: "won",
+			match: func() *Match {
+				m := startedMatch()
+				m.Winner = "alice"
+				m.Over = true
+				return m
+			},
+			wantErr: ErrGameOver,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.match()
+			events, err := m.TimeUp()
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := messages(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+			if m.Winner != "" || m.Reason != ReasonTimeUp || !m.Over {
+				t.Errorf("match = %+v, want a draw by time up", m)
+			}
+		})
+	}
+}
+
+func TestCheckAnswer(t *testing.T) {
+	all := []string{OpPlus, OpMinus, OpTimes, OpDivide}
+	leftToRight := Spec{Target: 2, Operators: all, Rule: RuleLeftToRight}
+	precedence := Spec{Target: 2, Operators: all, Rule: RulePrecedence}
+
+	tests := []struct {
+		name    string
+		spec    Spec
+		problem []int
+		answer  []string
+		want    bool
+	}{
+		{"plus and minus", DefaultSpec, []int{3, 2, 1}, []string{OpMinus, OpPlus}, true},
+		{"wrong", DefaultSpec, []int{3, 2, 1}, []string{OpPlus, OpPlus}, false},
+		{"not allowed", DefaultSpec, []int{1, 2}, []string{OpTimes}, false},
+		{"too few operators", DefaultSpec, []int{3, 2, 1}, []string{OpMinus}, false},
+		{"left to right", leftToRight, []int{3, 2, 2}, []string{OpMinus, OpTimes}, true},
+		{"left to right ignores precedence", leftToRight, []int{8, 2, 3}, []string{OpMinus, OpTimes}, false},
+		{"precedence", precedence, []int{8, 2, 3}, []string{OpMinus, OpTimes}, true},
+		{"precedence ignores the order", precedence, []int{3, 2, 2}, []string{OpMinus, OpTimes}, false},
+		{"divide", leftToRight, []int{6, 3}, []string{OpDivide}, true},
+		{"divide with a remainder", leftToRight, []int{7, 3}, []string{OpDivide}, false},
+		{"divide by zero", precedence, []int{2, 0}, []string{OpDivide}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckAnswer(tt.spec, tt.problem, tt.answer)
+			if got != tt.want {
+				t.Errorf("CheckAnswer(%v, %v) = %v, want %v", tt.problem, tt.answer, got, tt.want)
+			}
+		})
+	}
+}
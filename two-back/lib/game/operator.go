@@ -0,0 +1,38 @@
+package game
+
+import (
+	"github.com/uu64/two-apps/two-back/lib/solver"
+)
+
+// the operators of the answer
+const (
+	OpPlus   = solver.OpPlus
+	OpMinus  = solver.OpMinus
+	OpTimes  = solver.OpTimes
+	OpDivide = solver.OpDivide
+)
+
+// Rule is the order the operators are evaluated in
+type Rule = solver.Rule
+
+const (
+	// RuleLeftToRight evaluates every operator from left to right
+	RuleLeftToRight = solver.RuleLeftToRight
+	// RulePrecedence evaluates times and divide before plus and minus
+	RulePrecedence = solver.RulePrecedence
+)
+
+// knownOperator returns whether the operator is defined
+func knownOperator(op string) bool {
+	switch op {
+	case OpPlus, OpMinus, OpTimes, OpDivide:
+		return true
+	}
+	return false
+}
+
+// Evaluate returns the value the operators make from the terms under the rule.
+// ok is false when the expression is not valid.
+func Evaluate(rule Rule, terms []int, ops []string) (int, bool) {
+	return solver.Evaluate(rule, terms, ops)
+}
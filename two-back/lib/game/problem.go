@@ -0,0 +1,130 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/uu64/two-apps/two-back/lib/solver"
+)
+
+// ErrInvalidLevel is returned when the problem cannot be created at the level
+var ErrInvalidLevel = errors.New("INVALID_PARAMETER")
+
+// ErrNoProblem is returned when no problem is found for the spec in time
+var ErrNoProblem = errors.New("no problem is found")
+
+// maxCreateAttempts is the number of problems tried before giving up
+var maxCreateAttempts int = 1000
+
+// NewSeed returns a seed for a new problem
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// randomTerm returns a term in the range of the spec
+func randomTerm(rng *rand.Rand, spec Spec) int {
+	return spec.MinTerm + rng.Intn(spec.MaxTerm-spec.MinTerm+1)
+}
+
+// createAdditive makes the first term from the others,
+// so the problem always has an answer with plus and minus
+func createAdditive(rng *rand.Rand, spec Spec) []int {
+	terms := make([]int, spec.Terms)
+
+	sum := spec.Target
+	for i := 0; i < spec.Terms-1; i++ {
+		term := randomTerm(rng, spec)
+		switch spec.Operators[rng.Intn(len(spec.Operators))] {
+		case OpPlus:
+			sum = sum + term
+		case OpMinus:
+			sum = sum - term
+		}
+		terms[spec.Terms-1-i] = term
+	}
+	terms[0] = sum
+
+	return terms
+}
+
+// createRandom picks every term in the range of the spec
+func createRandom(rng *rand.Rand, spec Spec) []int {
+	terms := make([]int, spec.Terms)
+	for i := range terms {
+		terms[i] = randomTerm(rng, spec)
+	}
+	return terms
+}
+
+// CreateProblem creates a problem of the spec.
+// The problem is solvable with the operators of the spec.
+// Problems the filter of the spec rejects are regenerated,
+// and the rest are retried until one falls in the difficulty band,
+// and the closest one is taken when none does.
+// The same seed and spec always create the same problem.
+func CreateProblem(spec Spec, seed int64) ([]int, error) {
+	return createProblem(spec, seed, Rejected)
+}
+
+// createProblem creates a problem of the spec
+// and counts the rejected ones to rejected
+func createProblem(spec Spec, seed int64, rejected *Rejections) ([]int, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+
+	var closest []int
+	closestDistance := 0.0
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		var terms []int
+		if spec.additive() {
+			terms = createAdditive(rng, spec)
+		} else {
+			terms = createRandom(rng, spec)
+		}
+
+		features, reason := check(spec, terms)
+		if reason != "" {
+			rejected.add(reason)
+			continue
+		}
+
+		distance := spec.Difficulty.distance(features.Score(len(spec.Operators)))
+		if distance == 0 {
+			return terms, nil
+		}
+		rejected.add(RejectOutOfBand)
+		if closest == nil || distance < closestDistance {
+			closest, closestDistance = terms, distance
+		}
+	}
+
+	if closest == nil {
+		return nil, ErrNoProblem
+	}
+	return closest, nil
+}
+
+// CountSolutions returns the number of the answers of the problem
+func CountSolutions(spec Spec, problem []int) int {
+	return solver.Count(spec.Problem(problem))
+}
+
+// CheckAnswer returns whether the operators make the target of the spec from the problem
+func CheckAnswer(spec Spec, problem []int, answer []string) bool {
+	for _, op := range answer {
+		if !spec.Allows(op) {
+			return false
+		}
+	}
+
+	num, ok := Evaluate(spec.Rule, problem, answer)
+	if !ok || num != spec.Target {
+		return false
+	}
+
+	return true
+}
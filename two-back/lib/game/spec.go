@@ -0,0 +1,133 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/uu64/two-apps/two-back/lib/solver"
+)
+
+// ErrInvalidSpec is returned when no problem can be created with the spec
+var ErrInvalidSpec = errors.New("invalid problem spec")
+
+// Spec describes the problems of a level
+type Spec struct {
+	// Target is the number the operators have to make
+	Target int `json:"target"`
+	// MinTerm and MaxTerm are the range of the terms but the first one
+	MinTerm int `json:"minTerm"`
+	MaxTerm int `json:"maxTerm"`
+	// Terms is the number of the terms
+	Terms int `json:"terms"`
+	// Negatives allows the terms to be negative
+	Negatives bool `json:"negatives"`
+	// Operators are the operators the answer can use
+	Operators []string `json:"operators"`
+	// Rule is the order the operators are evaluated in
+	Rule Rule `json:"rule"`
+	// Difficulty is the band the score of the problem falls in.
+	// The zero band accepts any problem.
+	Difficulty Band `json:"difficulty"`
+	// Filter is the rules rejecting the degenerate problems
+	Filter Filter `json:"filter"`
+}
+
+// DefaultSpec is the spec of every level but the number of the terms
+var DefaultSpec = Spec{
+	Target:    2,
+	MinTerm:   0,
+	MaxTerm:   9,
+	Operators: []string{OpPlus, OpMinus},
+	Rule:      RuleLeftToRight,
+	Filter:    DefaultFilter,
+}
+
+// LevelOperators are the operators of the levels
+// which use more than the operators of DefaultSpec
+var LevelOperators = map[int][]string{
+	7:  {OpPlus, OpMinus, OpTimes},
+	8:  {OpPlus, OpMinus, OpTimes},
+	9:  {OpPlus, OpMinus, OpTimes, OpDivide},
+	10: {OpPlus, OpMinus, OpTimes, OpDivide},
+}
+
+// SpecForLevel returns the spec of the problems of the level.
+// The level is the number of the terms and the band of the difficulty.
+func SpecForLevel(level int) (Spec, error) {
+	if err := ValidateLevel(level); err != nil {
+		return Spec{}, err
+	}
+
+	spec := DefaultSpec
+	spec.Terms = level
+	spec.Difficulty = BandForLevel(level)
+	if ops, ok := LevelOperators[level]; ok {
+		spec.Operators = ops
+	}
+	spec = spec.WithFilter(DefaultFilter)
+	return spec, spec.Validate()
+}
+
+// WithFilter returns the spec rejecting the problems by the filter
+func (s Spec) WithFilter(f Filter) Spec {
+	// a single term has no operator to choose
+	if s.Terms < 2 {
+		f.RequireMinus = false
+	}
+	s.Filter = f
+	return s
+}
+
+// Validate returns ErrInvalidSpec when no problem can be created with the spec
+func (s Spec) Validate() error {
+	if s.Terms < 1 || s.MinTerm > s.MaxTerm || s.Difficulty.Min > s.Difficulty.Max {
+		return ErrInvalidSpec
+	}
+	if !s.Negatives && (s.MinTerm < 0 || s.Target < 0) {
+		return ErrInvalidSpec
+	}
+	if s.Filter.MaxSolutions < 0 || (s.Filter.RequireMinus && s.Terms < 2) {
+		return ErrInvalidSpec
+	}
+	if s.Rule != RuleLeftToRight && s.Rule != RulePrecedence {
+		return ErrInvalidSpec
+	}
+	if len(s.Operators) == 0 {
+		return ErrInvalidSpec
+	}
+	for _, op := range s.Operators {
+		if !knownOperator(op) {
+			return ErrInvalidSpec
+		}
+	}
+	return nil
+}
+
+// Allows returns whether the answer can use the operator
+func (s Spec) Allows(op string) bool {
+	for _, o := range s.Operators {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Problem returns the problem of the terms for the solver
+func (s Spec) Problem(terms []int) solver.Problem {
+	return solver.Problem{
+		Terms:     terms,
+		Target:    s.Target,
+		Operators: s.Operators,
+		Rule:      s.Rule,
+	}
+}
+
+// additive returns whether the answer can use only plus and minus
+func (s Spec) additive() bool {
+	for _, op := range s.Operators {
+		if op != OpPlus && op != OpMinus {
+			return false
+		}
+	}
+	return true
+}
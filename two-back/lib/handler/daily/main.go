@@ -0,0 +1,182 @@
+package daily
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/reply"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/repository/results"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+)
+
+type request = events.APIGatewayWebsocketProxyRequest
+type response = events.APIGatewayProxyResponse
+
+// Handler handles the daily route.
+// The daily challenge is played alone, so it uses no room,
+// and the results are kept by the player across the connections.
+type Handler struct {
+	Results  results.ResultStore
+	Users    users.UserStore
+	Notifier ws.NotifierFactory
+	// Clock returns the current time, or time.Now when nil
+	Clock func() time.Time
+}
+
+// incoming is the body of the daily route.
+// The problem of the day is sent without the answer.
+type incoming struct {
+	Answer []string `json:"answer"`
+}
+
+func (h *Handler) now() time.Time {
+	if h.Clock == nil {
+		return time.Now()
+	}
+	return h.Clock()
+}
+
+// playerID returns the player the results of the user are kept for.
+// The anonymous user has the results of the connection.
+func (h *Handler) playerID(connectionID string) (string, error) {
+	playerID, err := h.Users.PlayerID(connectionID)
+	if err != nil || playerID != "" {
+		return playerID, err
+	}
+	return connectionID, nil
+}
+
+// challenge gives the problem of the day and starts the clock of the player
+func (h *Handler) challenge(connectionID string, now time.Time) ([]game.Event, error) {
+	spec, problem, err := game.DailyProblem(now)
+	if err != nil {
+		return nil, err
+	}
+
+	playerID, err := h.playerID(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	date := game.DailyDate(now)
+	err = h.Results.Start(date, playerID, now)
+	if err != nil {
+		return nil, err
+	}
+	// the answer is judged against this day even after midnight
+	err = h.Users.SetDailyDate(connectionID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return []game.Event{
+		{
+			To:      []string{connectionID},
+			Message: "DAILY_CHALLENGE",
+			Problem: problem,
+			Spec:    &spec,
+			Level:   game.DailyLevel,
+			Date:    date,
+		},
+	}, nil
+}
+
+// judge checks the answer to the problem of the day the user has started
+// and records the solve time once it is correct
+func (h *Handler) judge(connectionID string, answer []string, now time.Time) ([]game.Event, error) {
+	date, err := h.Users.DailyDate(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if date == "" {
+		return nil, game.ErrNotStarted
+	}
+	day, err := game.ParseDailyDate(date)
+	if err != nil {
+		return nil, err
+	}
+	spec, problem, err := game.DailyProblem(day)
+	if err != nil {
+		return nil, err
+	}
+
+	if !game.CheckAnswer(spec, problem, answer) {
+		return []game.Event{
+			{To: []string{connectionID}, Message: "WRONG_ANSWER"},
+		}, nil
+	}
+
+	playerID, err := h.playerID(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := h.Results.Solve(date, playerID, now)
+	if err == results.ErrResultNotFound {
+		return nil, game.ErrNotStarted
+	}
+	if err == results.ErrAlreadySolved {
+		return nil, game.ErrAlreadySolved
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rank, err := h.Results.Rank(date, result.SolveTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return []game.Event{
+		{
+			To:        []string{connectionID},
+			Message:   "DAILY_SOLVED",
+			Date:      date,
+			Rank:      rank,
+			SolveTime: result.SolveTime,
+		},
+	}, nil
+}
+
+// Handle gives the problem of the day,
+// or judges the answer of the user when it is sent
+func (h *Handler) Handle(ctx context.Context, request request) (response, error) {
+	connectionID := request.RequestContext.ConnectionID
+	notifier := h.Notifier(ws.Endpoint(
+		request.RequestContext.DomainName, request.RequestContext.Stage))
+
+	// parse request body
+	var incoming incoming
+	err := json.Unmarshal([]byte(request.Body), &incoming)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	var events []game.Event
+	now := h.now()
+	if incoming.Answer == nil {
+		events, err = h.challenge(connectionID, now)
+	} else {
+		events, err = h.judge(connectionID, incoming.Answer, now)
+	}
+	if err == game.ErrNotStarted || err == game.ErrAlreadySolved {
+		return reply.Reject(notifier, connectionID, err.Error())
+	}
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	// reply
+	err = reply.Publish(notifier, events)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	return response{StatusCode: 200}, nil
+}
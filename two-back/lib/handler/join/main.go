@@ -0,0 +1,373 @@
+package join
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/matchmaker"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+type request = events.APIGatewayWebsocketProxyRequest
+type response = events.APIGatewayProxyResponse
+
+// maxMatchAttempts is the number of waiting rooms tried before creating a new room
+var maxMatchAttempts int = 5
+
+// ModeDaily is the mode of the connection playing the daily challenge,
+// which is kept out of the rooms
+const ModeDaily string = "daily"
+
+// DefaultWaitingTimeout is how long a room waits for a challenger
+var DefaultWaitingTimeout time.Duration = 60 * time.Second
+
+// Handler handles the $connect route
+type Handler struct {
+	Rooms      rooms.RoomStore
+	Users      users.UserStore
+	Matchmaker matchmaker.Matchmaker
+	Announcer  matchmaker.Announcer
+	Scheduler  scheduler.Scheduler
+	// WaitingTimeout is DefaultWaitingTimeout when it is zero
+	WaitingTimeout time.Duration
+}
+
+func (h *Handler) waitingTimeout() time.Duration {
+	if h.WaitingTimeout == 0 {
+		return DefaultWaitingTimeout
+	}
+	return h.WaitingTimeout
+}
+
+func (h *Handler) createRoom(connectionID string, level int, endpoint string) (string, error) {
+	var roomID string
+
+	// create room
+	roomID, err := h.Rooms.Create(connectionID)
+	if err != nil {
+		return roomID, err
+	}
+
+	// wait a new challenger until the timeout
+	err = h.Scheduler.Schedule(scheduler.Job{
+		Kind:     scheduler.JobWaitingTimeout,
+		RoomID:   roomID,
+		Endpoint: endpoint,
+	}, h.waitingTimeout())
+	if err != nil {
+		return roomID, err
+	}
+
+	// look for the rooms of the other brackets once the room accepts any level,
+	// since the rooms already waiting are matched only by a new user
+	crossAfter := h.Matchmaker.CrossAfter()
+	if level != 0 && crossAfter > 0 && crossAfter < h.waitingTimeout() {
+		err = h.Scheduler.Schedule(scheduler.Job{
+			Kind:     scheduler.JobCrossMatch,
+			RoomID:   roomID,
+			Endpoint: endpoint,
+		}, crossAfter)
+		if err != nil {
+			return roomID, err
+		}
+	}
+
+	err = h.Matchmaker.Enqueue(roomID, level)
+	return roomID, err
+}
+
+func (h *Handler) addUser(connectionID string, playerID string, roomID string, level int) error {
+	return h.Users.Create(connectionID, playerID, roomID, level)
+}
+
+func (h *Handler) updateRoom(roomID string, connectionID string, ticket string) error {
+	// update room
+	err := h.Rooms.AddUser(roomID, connectionID)
+	if err == rooms.ErrRoomNotWaiting {
+		// the room is no longer waiting, so drop it from the queue
+		h.Matchmaker.Confirm(ticket)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// remove the room from the queue
+	err = h.Matchmaker.Confirm(ticket)
+	return err
+}
+
+// isWaiting returns whether the room still exists and waits for a challenger
+func (h *Handler) isWaiting(roomID string) (bool, error) {
+	status, err := h.Rooms.Status(roomID)
+	if err == rooms.ErrRoomNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return status == rooms.RoomStatusWaiting, nil
+}
+
+// isSamePlayer returns whether the creator of the room plays as the player.
+// The anonymous users are never the same player.
+func (h *Handler) isSamePlayer(roomID string, playerID string) (bool, error) {
+	if playerID == "" {
+		return false, nil
+	}
+	userIDs, err := h.Rooms.Users(roomID)
+	if err != nil {
+		return false, err
+	}
+	creator, err := h.Users.PlayerID(userIDs[0])
+	if err == users.ErrUserNotFound {
+		// the creator has just left
+		return false, nil
+	}
+	return creator == playerID, err
+}
+
+// release puts the skipped rooms back for the others
+func (h *Handler) release(tickets []string) {
+	for _, ticket := range tickets {
+		if err := h.Matchmaker.Release(ticket); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// nextRoom takes the next waiting room the player of the level can join.
+// The dead rooms are dropped, and the rooms of the same player and the excluded room
+// are added to skipped, which the caller releases once done.
+func (h *Handler) nextRoom(playerID string, level int, exclude string, skipped *[]string) (string, string, bool, error) {
+	for {
+		roomID, ticket, ok, err := h.Matchmaker.TryMatch(level)
+		if err != nil || !ok {
+			return roomID, ticket, false, err
+		}
+
+		waiting, err := h.isWaiting(roomID)
+		if err != nil {
+			return roomID, ticket, false, err
+		}
+		if !waiting {
+			// the waiting user has left, drop the dead ticket
+			fmt.Println("skip dead room")
+			err = h.Matchmaker.Confirm(ticket)
+			if err != nil {
+				return roomID, ticket, false, err
+			}
+			continue
+		}
+		if roomID == exclude {
+			*skipped = append(*skipped, ticket)
+			continue
+		}
+
+		same, err := h.isSamePlayer(roomID, playerID)
+		if err != nil {
+			return roomID, ticket, false, err
+		}
+		if same {
+			// nobody plays against oneself
+			fmt.Println("skip own room")
+			*skipped = append(*skipped, ticket)
+			continue
+		}
+		return roomID, ticket, true, nil
+	}
+}
+
+// matchRoom puts the user into a waiting room of the same bracket or a new room.
+// The rooms of the same player are skipped, so nobody plays against oneself.
+// It also returns true when the user has joined a waiting room.
+func (h *Handler) matchRoom(connectionID string, playerID string, level int, endpoint string) (string, bool, error) {
+	var skipped []string
+	defer func() {
+		h.release(skipped)
+	}()
+
+	attempts := 0
+	for attempts < maxMatchAttempts {
+		roomID, ticket, ok, err := h.nextRoom(playerID, level, "", &skipped)
+		if err != nil {
+			return roomID, false, err
+		}
+		if !ok {
+			break
+		}
+
+		attempts++
+		err = h.updateRoom(roomID, connectionID, ticket)
+		if err == rooms.ErrRoomNotWaiting {
+			// another challenger claimed the room first, try the next one
+			fmt.Println("room is already taken")
+			continue
+		}
+		if err != nil {
+			return roomID, false, err
+		}
+
+		fmt.Println("match complete")
+		return roomID, true, nil
+	}
+
+	fmt.Println("create room")
+	roomID, err := h.createRoom(connectionID, level, endpoint)
+	return roomID, false, err
+}
+
+// CrossMatch moves the creator of the waiting room into a waiting room
+// of any bracket, once the room has waited long enough to accept any level.
+// The room is left waiting when no other room is waiting.
+func (h *Handler) CrossMatch(roomID string, endpoint string) error {
+	waiting, err := h.isWaiting(roomID)
+	if err != nil || !waiting {
+		return err
+	}
+	userIDs, err := h.Rooms.Users(roomID)
+	if err != nil {
+		return err
+	}
+	creator := userIDs[0]
+	playerID, err := h.Users.PlayerID(creator)
+	if err == users.ErrUserNotFound {
+		// the creator has just left
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	level, err := h.Users.Level(creator)
+	if err != nil {
+		return err
+	}
+
+	var skipped []string
+	defer func() {
+		h.release(skipped)
+	}()
+
+	// the room accepts any level now
+	other, ticket, ok, err := h.nextRoom(playerID, 0, roomID, &skipped)
+	if err != nil || !ok {
+		return err
+	}
+
+	err = h.Rooms.Transition(roomID, rooms.RoomStatusWaiting, rooms.RoomStatusAbandoned)
+	if err == rooms.ErrInvalidTransition {
+		// a challenger has joined the room just now
+		skipped = append(skipped, ticket)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = h.Matchmaker.Cancel(roomID)
+	if err != nil {
+		return err
+	}
+
+	err = h.updateRoom(other, creator, ticket)
+	if err == rooms.ErrRoomNotWaiting {
+		// the other room has been taken meanwhile, so match the creator again
+		return h.Join(creator, playerID, endpoint, level)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("cross match complete")
+	err = h.addUser(creator, playerID, other, level)
+	if err != nil {
+		return err
+	}
+	return h.Announcer.Announce(matchmaker.Announcement{
+		RoomID:   other,
+		Endpoint: endpoint,
+	})
+}
+
+// parseLevel returns the level in the query string, or 0 when it is not given
+func parseLevel(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	level, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, game.ErrInvalidLevel
+	}
+	return level, game.ValidateMatchLevel(level)
+}
+
+// Join puts the user into a room and announces the room once it is matched.
+// playerID is the player the user plays as,
+// endpoint is the websocket api the user is connected to,
+// and level is the level the user wants or 0 for any level.
+func (h *Handler) Join(connectionID string, playerID string, endpoint string, level int) error {
+	roomID, matched, err := h.matchRoom(connectionID, playerID, level, endpoint)
+	if err != nil {
+		return err
+	}
+
+	err = h.addUser(connectionID, playerID, roomID, level)
+	if err != nil {
+		return err
+	}
+
+	// the game is started by the announcement,
+	// since the new connection cannot receive messages until $connect returns
+	if matched {
+		return h.Announcer.Announce(matchmaker.Announcement{
+			RoomID:   roomID,
+			Endpoint: endpoint,
+		})
+	}
+	return nil
+}
+
+// Handle puts the connected user into a room.
+// The user can ask for the level with the "level" query string,
+// and plays the daily challenge alone with the "mode=daily" query string.
+// The "player" query string names the player whose rating is kept
+// across the connections. It is not authenticated,
+// and the user without it plays anonymously without the rating.
+func (h *Handler) Handle(ctx context.Context, request request) (response, error) {
+	fmt.Println("connected!!!!!!!")
+
+	connectionID := request.RequestContext.ConnectionID
+	playerID := request.QueryStringParameters["player"]
+
+	if request.QueryStringParameters["mode"] == ModeDaily {
+		// the user of the daily challenge belongs to no room
+		err := h.addUser(connectionID, playerID, "", 0)
+		if err != nil {
+			fmt.Println(err)
+			return response{StatusCode: 500}, err
+		}
+		return response{StatusCode: 200}, nil
+	}
+
+	level, err := parseLevel(request.QueryStringParameters["level"])
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 400}, nil
+	}
+
+	err = h.Join(connectionID, playerID, ws.Endpoint(
+		request.RequestContext.DomainName, request.RequestContext.Stage), level)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	return response{StatusCode: 200}, nil
+}
@@ -0,0 +1,153 @@
+package leave
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/handler/reply"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/matchmaker"
+	"github.com/uu64/two-apps/two-back/lib/rating"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+)
+
+type request = events.APIGatewayWebsocketProxyRequest
+type response = events.APIGatewayProxyResponse
+
+// maxLeaveAttempts is the number of times leaving is retried
+// when the room changes its status meanwhile
+var maxLeaveAttempts int = 3
+
+// Handler handles the $disconnect route
+type Handler struct {
+	Rooms      rooms.RoomStore
+	Users      users.UserStore
+	Matchmaker matchmaker.Matchmaker
+	Notifier   ws.NotifierFactory
+	Rater      *rating.Rater
+	// Clock returns the current time, or time.Now when nil
+	Clock func() time.Time
+}
+
+func (h *Handler) now() time.Time {
+	if h.Clock == nil {
+		return time.Now()
+	}
+	return h.Clock()
+}
+
+// onWaiting withdraws the room from the matchmaking
+func (h *Handler) onWaiting(room rooms.Room) error {
+	err := h.Rooms.Transition(room.RoomID, room.Status, rooms.RoomStatusAbandoned)
+	if err != nil {
+		return err
+	}
+
+	return h.Matchmaker.Cancel(room.RoomID)
+}
+
+// onPlaying gives up the game and lets the opponent win by forfeit,
+// or ends the game as a draw when the time is already up
+func (h *Handler) onPlaying(notifier ws.Notifier, room rooms.Room, connectionID string) error {
+	match := room.Match()
+	events, err := match.Forfeit(connectionID, h.now())
+	if err != nil {
+		return err
+	}
+
+	if match.Reason != "" {
+		finished, err := h.Rooms.Finish(room.RoomID, match.Winner, match.Reason)
+		if err != nil {
+			return err
+		}
+		if !finished {
+			return rooms.ErrInvalidTransition
+		}
+		events = h.Rater.TryRate(match, events)
+	} else {
+		err = h.Rooms.Transition(room.RoomID, room.Status, rooms.RoomStatusAbandoned)
+		if err != nil {
+			return err
+		}
+	}
+
+	return reply.Publish(notifier, events)
+}
+
+// inRoom returns whether the user still belongs to the room.
+// The user may have gone back to the matchmaking after the match was cancelled.
+func (h *Handler) inRoom(userID string, roomID string) bool {
+	current, err := h.Users.RoomID(userID)
+	return err == nil && current == roomID
+}
+
+func (h *Handler) closeRoom(notifier ws.Notifier, roomID string, connectionID string) (rooms.Room, error) {
+	var room rooms.Room
+	var err error
+
+	for i := 0; i < maxLeaveAttempts; i++ {
+		room, err = h.Rooms.Get(roomID)
+		if err != nil {
+			return room, err
+		}
+
+		switch room.Status {
+		case rooms.RoomStatusWaiting:
+			err = h.onWaiting(room)
+		case rooms.RoomStatusPreparing, rooms.RoomStatusPlaying:
+			err = h.onPlaying(notifier, room, connectionID)
+		}
+
+		// retry only when the room has changed its status meanwhile
+		if err != rooms.ErrInvalidTransition {
+			return room, err
+		}
+	}
+
+	return room, err
+}
+
+// Handle closes the room the disconnected user belongs to
+func (h *Handler) Handle(ctx context.Context, request request) (response, error) {
+	fmt.Println("disconnected!!!!!!!")
+
+	connectionID := request.RequestContext.ConnectionID
+	notifier := h.Notifier(ws.Endpoint(
+		request.RequestContext.DomainName, request.RequestContext.Stage))
+
+	roomID, err := h.Users.RoomID(connectionID)
+	if err == users.ErrUserNotFound {
+		// the user has been closed by the server already
+		return response{StatusCode: 200}, nil
+	}
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+	if roomID == "" {
+		// the user has played the daily challenge alone
+		h.Users.Delete(connectionID)
+		return response{StatusCode: 200}, nil
+	}
+
+	room, err := h.closeRoom(notifier, roomID, connectionID)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	// the room is kept as the record of the game until it expires
+	h.Users.Delete(connectionID)
+	for _, userID := range []string{room.User1ID, room.User2ID} {
+		if userID == "" || userID == connectionID || !h.inRoom(userID, room.RoomID) {
+			continue
+		}
+		notifier.Disconnect(userID)
+		h.Users.Delete(userID)
+	}
+
+	return response{StatusCode: 200}, nil
+}
@@ -0,0 +1,76 @@
+package problem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/reply"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+)
+
+type request = events.APIGatewayWebsocketProxyRequest
+type response = events.APIGatewayProxyResponse
+
+// Handler handles the problem route
+type Handler struct {
+	Rooms    rooms.RoomStore
+	Users    users.UserStore
+	Notifier ws.NotifierFactory
+}
+
+func (h *Handler) getRoom(connectionID string) (rooms.Room, error) {
+	roomID, err := h.Users.RoomID(connectionID)
+	if err != nil {
+		return rooms.Room{}, err
+	}
+	return h.Rooms.Get(roomID)
+}
+
+func (h *Handler) onWaiting(notifier ws.Notifier, connectionID string) error {
+	return reply.Send(notifier, connectionID, "PLEASE_WAIT")
+}
+
+// onPreparing asks the user to get ready, since the game starts
+// only after both users have sent ready
+func (h *Handler) onPreparing(notifier ws.Notifier, connectionID string) error {
+	return reply.Send(notifier, connectionID, "PLEASE_READY")
+}
+
+// Handle tells the user what to do until the game starts
+func (h *Handler) Handle(ctx context.Context, request request) (response, error) {
+	connectionID := request.RequestContext.ConnectionID
+	notifier := h.Notifier(ws.Endpoint(
+		request.RequestContext.DomainName, request.RequestContext.Stage))
+
+	// check room status
+	room, err := h.getRoom(connectionID)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	switch room.Status {
+	case rooms.RoomStatusWaiting:
+		err = h.onWaiting(notifier, connectionID)
+	case rooms.RoomStatusPreparing:
+		err = h.onPreparing(notifier, connectionID)
+	case rooms.RoomStatusPlaying:
+		err = game.ErrAlreadyStarted
+	default:
+		err = game.ErrGameOver
+	}
+
+	if err == game.ErrAlreadyStarted || err == game.ErrGameOver {
+		return reply.Reject(notifier, connectionID, err.Error())
+	}
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	return response{StatusCode: 200}, nil
+}
@@ -0,0 +1,222 @@
+package ready
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/reply"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+type request = events.APIGatewayWebsocketProxyRequest
+type response = events.APIGatewayProxyResponse
+
+// DefaultLevel is the level of the problem when the users do not choose
+var DefaultLevel int = 5
+
+// DefaultLevelPolicy decides the level when the handler has no policy
+var DefaultLevelPolicy game.LevelPolicy = game.LevelPolicyMin
+
+// Handler handles the ready route
+type Handler struct {
+	Rooms       rooms.RoomStore
+	Users       users.UserStore
+	Notifier    ws.NotifierFactory
+	Scheduler   scheduler.Scheduler
+	LevelPolicy game.LevelPolicy
+	// Filter rejects the degenerate problems, or game.DefaultFilter when nil
+	Filter *game.Filter
+	// Clock returns the current time, or time.Now when nil
+	Clock func() time.Time
+}
+
+type incoming struct {
+	// Level is the level the user prefers, or 0 without preference
+	Level int `json:"level"`
+}
+
+func (h *Handler) levelPolicy() game.LevelPolicy {
+	if h.LevelPolicy == "" {
+		return DefaultLevelPolicy
+	}
+	return h.LevelPolicy
+}
+
+func (h *Handler) filter() game.Filter {
+	if h.Filter == nil {
+		return game.DefaultFilter
+	}
+	return *h.Filter
+}
+
+func (h *Handler) now() time.Time {
+	if h.Clock == nil {
+		return time.Now()
+	}
+	return h.Clock()
+}
+
+// level resolves the level of the match from the levels the players prefer
+func (h *Handler) level(match *game.Match) (int, error) {
+	levels := make([]int, len(match.Players))
+	for i, player := range match.Players {
+		level, err := h.Users.Level(player)
+		if err != nil {
+			return 0, err
+		}
+		levels[i] = level
+	}
+	return h.levelPolicy().Resolve(levels, DefaultLevel), nil
+}
+
+// refusal returns the message for the user who cannot get ready in the room
+func (h *Handler) refusal(roomID string) (string, error) {
+	status, err := h.Rooms.Status(roomID)
+	if err != nil {
+		return "", err
+	}
+
+	switch status {
+	case rooms.RoomStatusWaiting:
+		return "PLEASE_WAIT", nil
+	case rooms.RoomStatusPlaying:
+		return game.ErrAlreadyStarted.Error(), nil
+	}
+	return game.ErrGameOver.Error(), nil
+}
+
+// startGame starts the game once both users are ready.
+// The game ends when the time limit passes.
+func (h *Handler) startGame(notifier ws.Notifier, endpoint string, room rooms.Room) error {
+	match := room.Match()
+	for _, player := range match.Players {
+		if !room.IsReady(player) {
+			return nil
+		}
+	}
+
+	level, err := h.level(match)
+	if err != nil {
+		return err
+	}
+
+	events, err := match.Start(level, h.filter(), h.now())
+	// the counts are kept only while the process lives,
+	// so they are logged for the functions without the debug endpoint
+	fmt.Println("rejected problems:", game.Rejected.Counts())
+	if err == game.ErrNoProblem {
+		return h.cancel(notifier, room)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = h.Rooms.StartGame(room.RoomID, match.Problem, match.Seed, match.Spec, match.TimeLimit, match.StartedAt)
+	if err == rooms.ErrInvalidTransition {
+		// the match has been cancelled or started meanwhile
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = h.Scheduler.Schedule(scheduler.Job{
+		Kind:     scheduler.JobTimeUp,
+		RoomID:   room.RoomID,
+		Endpoint: endpoint,
+	}, time.Duration(match.TimeLimit)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	return reply.Publish(notifier, events)
+}
+
+// cancel abandons the room when no problem can be created for it.
+// The users are disconnected rather than matched again for the same problem.
+func (h *Handler) cancel(notifier ws.Notifier, room rooms.Room) error {
+	err := h.Rooms.Transition(room.RoomID, room.Status, rooms.RoomStatusAbandoned)
+	if err == rooms.ErrInvalidTransition {
+		// the match has been cancelled meanwhile
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	match := room.Match()
+	err = reply.Publish(notifier, []game.Event{
+		{To: match.Players, Message: "NO_PROBLEM"},
+	})
+	if err != nil {
+		return err
+	}
+	for _, player := range match.Players {
+		// the user is deleted by $disconnect
+		notifier.Disconnect(player)
+	}
+	return nil
+}
+
+// Handle marks the user as ready and starts the game when both users are ready.
+// The level the user prefers is stored with the user.
+func (h *Handler) Handle(ctx context.Context, request request) (response, error) {
+	connectionID := request.RequestContext.ConnectionID
+	endpoint := ws.Endpoint(
+		request.RequestContext.DomainName, request.RequestContext.Stage)
+	notifier := h.Notifier(endpoint)
+
+	// parse request body
+	var incoming incoming
+	err := json.Unmarshal([]byte(request.Body), &incoming)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+	if err = game.ValidateMatchLevel(incoming.Level); err != nil {
+		return reply.Reject(notifier, connectionID, err.Error())
+	}
+
+	roomID, err := h.Users.RoomID(connectionID)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	if incoming.Level > 0 {
+		err = h.Users.SetLevel(connectionID, incoming.Level)
+		if err != nil {
+			fmt.Println(err)
+			return response{StatusCode: 500}, err
+		}
+	}
+
+	room, err := h.Rooms.Ready(roomID, connectionID)
+	if err == rooms.ErrInvalidTransition {
+		message, err := h.refusal(roomID)
+		if err != nil {
+			fmt.Println(err)
+			return response{StatusCode: 500}, err
+		}
+		return reply.Reject(notifier, connectionID, message)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	err = h.startGame(notifier, endpoint, room)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	return response{StatusCode: 200}, nil
+}
@@ -0,0 +1,46 @@
+package reply
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+)
+
+type response = events.APIGatewayProxyResponse
+
+// Publish sends each event to its players
+func Publish(notifier ws.Notifier, events []game.Event) error {
+	for _, e := range events {
+		data, err := json.Marshal(&e)
+		if err != nil {
+			return err
+		}
+
+		err = notifier.Broadcast(e.To, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send sends the message to the user
+func Send(notifier ws.Notifier, connectionID string, message string) error {
+	return Publish(notifier, []game.Event{
+		{To: []string{connectionID}, Message: message},
+	})
+}
+
+// Reject tells the user why the request is rejected
+// and returns the response of the rejected request
+func Reject(notifier ws.Notifier, connectionID string, message string) (response, error) {
+	err := Send(notifier, connectionID, message)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+	return response{StatusCode: 400}, nil
+}
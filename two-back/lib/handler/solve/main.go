@@ -0,0 +1,111 @@
+package solve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/reply"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/rating"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+)
+
+type request = events.APIGatewayWebsocketProxyRequest
+type response = events.APIGatewayProxyResponse
+
+// Handler handles the solve route
+type Handler struct {
+	Rooms    rooms.RoomStore
+	Users    users.UserStore
+	Notifier ws.NotifierFactory
+	Rater    *rating.Rater
+	// Clock returns the current time, or time.Now when nil
+	Clock func() time.Time
+}
+
+type incoming struct {
+	Answer []string `json:"answer"`
+}
+
+func (h *Handler) now() time.Time {
+	if h.Clock == nil {
+		return time.Now()
+	}
+	return h.Clock()
+}
+
+func (h *Handler) getRoom(connectionID string) (rooms.Room, error) {
+	roomID, err := h.Users.RoomID(connectionID)
+	if err != nil {
+		return rooms.Room{}, err
+	}
+	return h.Rooms.Get(roomID)
+}
+
+func (h *Handler) judge(connectionID string, answer []string) ([]game.Event, error) {
+	room, err := h.getRoom(connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := room.Match()
+	events, err := match.Submit(connectionID, answer, h.now())
+	if err != nil || match.Winner != connectionID {
+		return events, err
+	}
+
+	// only the first correct answer can win the room
+	won, err := h.Rooms.Finish(room.RoomID, connectionID, match.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if !won {
+		// the game has ended meanwhile by another answer, time up or forfeit
+		return nil, game.ErrGameOver
+	}
+
+	h.Users.SolveProblem(connectionID)
+	return h.Rater.TryRate(match, events), nil
+}
+
+// Handle judges the answer of the user
+func (h *Handler) Handle(ctx context.Context, request request) (response, error) {
+	connectionID := request.RequestContext.ConnectionID
+	notifier := h.Notifier(ws.Endpoint(
+		request.RequestContext.DomainName, request.RequestContext.Stage))
+
+	// parse request body
+	var incoming incoming
+	err := json.Unmarshal([]byte(request.Body), &incoming)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	// check answer
+	events, err := h.judge(connectionID, incoming.Answer)
+	if err == game.ErrNotStarted || err == game.ErrGameOver {
+		return reply.Reject(notifier, connectionID, err.Error())
+	}
+	if err == game.ErrUnknownPlayer {
+		notifier.Disconnect(connectionID)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	// reply
+	err = reply.Publish(notifier, events)
+	if err != nil {
+		fmt.Println(err)
+		return response{StatusCode: 500}, err
+	}
+
+	return response{StatusCode: 200}, nil
+}
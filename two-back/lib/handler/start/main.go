@@ -0,0 +1,87 @@
+package start
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/reply"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/matchmaker"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+// DefaultReadyTimeout is how long the matched users have to get ready
+var DefaultReadyTimeout time.Duration = 10 * time.Second
+
+// Handler tells the matched users to get ready
+type Handler struct {
+	Rooms     rooms.RoomStore
+	Notifier  ws.NotifierFactory
+	Scheduler scheduler.Scheduler
+	// ReadyTimeout is DefaultReadyTimeout when it is zero
+	ReadyTimeout time.Duration
+}
+
+func (h *Handler) readyTimeout() time.Duration {
+	if h.ReadyTimeout == 0 {
+		return DefaultReadyTimeout
+	}
+	return h.ReadyTimeout
+}
+
+// Start sends MATCHED to both users and schedules the ready check of the room.
+// The game starts once both users are ready.
+func (h *Handler) Start(ctx context.Context, a matchmaker.Announcement) error {
+	notifier := h.Notifier(a.Endpoint)
+
+	room, err := h.Rooms.Get(a.RoomID)
+	if err != nil {
+		return err
+	}
+	if room.Status != rooms.RoomStatusPreparing {
+		// a user has left or the game has started already
+		return nil
+	}
+
+	timeout := h.readyTimeout()
+	err = h.Scheduler.Schedule(scheduler.Job{
+		Kind:     scheduler.JobReadyCheck,
+		RoomID:   room.RoomID,
+		Endpoint: a.Endpoint,
+	}, timeout)
+	if err != nil {
+		return err
+	}
+
+	return reply.Publish(notifier, []game.Event{
+		{
+			To:           room.Match().Players,
+			Message:      "MATCHED",
+			ReadyTimeout: int(timeout / time.Second),
+		},
+	})
+}
+
+// HandleSQS tells the users of the announcements in the queue
+func (h *Handler) HandleSQS(ctx context.Context, event events.SQSEvent) error {
+	for _, record := range event.Records {
+		var a matchmaker.Announcement
+		err := json.Unmarshal([]byte(record.Body), &a)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+
+		err = h.Start(ctx, a)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+	}
+	return nil
+}
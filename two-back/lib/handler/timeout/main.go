@@ -0,0 +1,198 @@
+package timeout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/handler/join"
+	"github.com/uu64/two-apps/two-back/lib/handler/reply"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/rating"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+// Handler runs the scheduled jobs of the rooms
+type Handler struct {
+	Rooms    rooms.RoomStore
+	Notifier ws.NotifierFactory
+	Rater    *rating.Rater
+	// Lobby puts the users back into the matchmaking
+	Lobby *join.Handler
+}
+
+// onReadyCheck cancels the match unless both users have got ready.
+// The ready user goes back into the matchmaking and the other is disconnected.
+func (h *Handler) onReadyCheck(notifier ws.Notifier, job scheduler.Job) error {
+	room, err := h.Rooms.Get(job.RoomID)
+	if err == rooms.ErrRoomNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if room.Status != rooms.RoomStatusPreparing {
+		// the game has started or a user has left
+		return nil
+	}
+
+	err = h.Rooms.Transition(room.RoomID, room.Status, rooms.RoomStatusAbandoned)
+	if err == rooms.ErrInvalidTransition {
+		// both users have got ready just now
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the ready users are matched again before the others are disconnected
+	var unready []string
+	for _, player := range room.Match().Players {
+		if !room.IsReady(player) {
+			unready = append(unready, player)
+			continue
+		}
+
+		level, err := h.Lobby.Users.Level(player)
+		if err != nil {
+			return err
+		}
+		playerID, err := h.Lobby.Users.PlayerID(player)
+		if err != nil {
+			return err
+		}
+		err = reply.Send(notifier, player, "MATCH_CANCELLED")
+		if err != nil {
+			return err
+		}
+		err = h.Lobby.Join(player, playerID, job.Endpoint, level)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, player := range unready {
+		// the user is deleted by $disconnect
+		err = reply.Send(notifier, player, "READY_TIMEOUT")
+		if err != nil {
+			return err
+		}
+		notifier.Disconnect(player)
+	}
+	return nil
+}
+
+// onWaitingTimeout closes the room nobody has joined.
+// The user is told NO_OPPONENT and disconnected after the records are deleted.
+func (h *Handler) onWaitingTimeout(notifier ws.Notifier, job scheduler.Job) error {
+	room, err := h.Rooms.Get(job.RoomID)
+	if err == rooms.ErrRoomNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if room.Status != rooms.RoomStatusWaiting {
+		return nil
+	}
+
+	err = h.Rooms.Transition(room.RoomID, room.Status, rooms.RoomStatusAbandoned)
+	if err == rooms.ErrInvalidTransition {
+		// a challenger has joined just now
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = h.Lobby.Matchmaker.Cancel(room.RoomID)
+	if err != nil {
+		return err
+	}
+
+	err = reply.Send(notifier, room.User1ID, "NO_OPPONENT")
+	if err != nil {
+		return err
+	}
+
+	err = h.Lobby.Users.Delete(room.User1ID)
+	if err != nil {
+		return err
+	}
+	err = h.Rooms.Delete(room.RoomID)
+	if err != nil {
+		return err
+	}
+
+	return notifier.Disconnect(room.User1ID)
+}
+
+// onTimeUp ends the game nobody has solved in time as a draw
+func (h *Handler) onTimeUp(notifier ws.Notifier, job scheduler.Job) error {
+	room, err := h.Rooms.Get(job.RoomID)
+	if err == rooms.ErrRoomNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if room.Status != rooms.RoomStatusPlaying {
+		// somebody has won or left
+		return nil
+	}
+
+	match := room.Match()
+	events, err := match.TimeUp()
+	if err != nil {
+		return err
+	}
+
+	finished, err := h.Rooms.Finish(room.RoomID, "", match.Reason)
+	if err != nil {
+		return err
+	}
+	if !finished {
+		// somebody has won just now
+		return nil
+	}
+
+	return reply.Publish(notifier, h.Rater.TryRate(match, events))
+}
+
+// Run runs the job
+func (h *Handler) Run(ctx context.Context, job scheduler.Job) error {
+	notifier := h.Notifier(job.Endpoint)
+
+	switch job.Kind {
+	case scheduler.JobReadyCheck:
+		return h.onReadyCheck(notifier, job)
+	case scheduler.JobWaitingTimeout:
+		return h.onWaitingTimeout(notifier, job)
+	case scheduler.JobTimeUp:
+		return h.onTimeUp(notifier, job)
+	case scheduler.JobCrossMatch:
+		return h.Lobby.CrossMatch(job.RoomID, job.Endpoint)
+	}
+	return fmt.Errorf("unknown job %s", job.Kind)
+}
+
+// HandleSQS runs the jobs in the queue
+func (h *Handler) HandleSQS(ctx context.Context, event events.SQSEvent) error {
+	for _, record := range event.Records {
+		var job scheduler.Job
+		err := json.Unmarshal([]byte(record.Body), &job)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+
+		err = h.Run(ctx, job)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+	}
+	return nil
+}
@@ -5,8 +5,9 @@ import (
 	"github.com/aws/aws-sdk-go/service/sqs"
 )
 
-// ReceiveMessage receives a message from the queue
-func ReceiveMessage(svc *sqs.SQS, queueName string) (*sqs.ReceiveMessageOutput, error) {
+// PeekMessages receives up to 10 messages from the queue.
+// The messages are hidden from the others for the visibility timeout.
+func PeekMessages(svc *sqs.SQS, queueName string, visibilityTimeout int64) (*sqs.ReceiveMessageOutput, error) {
 	var item *sqs.ReceiveMessageOutput
 
 	urlResult, err := svc.GetQueueUrl(&sqs.GetQueueUrlInput{
@@ -17,22 +18,39 @@ func ReceiveMessage(svc *sqs.SQS, queueName string) (*sqs.ReceiveMessageOutput,
 	}
 
 	item, err = svc.ReceiveMessage(&sqs.ReceiveMessageInput{
-		AttributeNames: []*string{
-			aws.String(sqs.MessageSystemAttributeNameSentTimestamp),
-		},
-		MessageAttributeNames: []*string{
-			aws.String(sqs.QueueAttributeNameAll),
-		},
 		QueueUrl:            urlResult.QueueUrl,
-		MaxNumberOfMessages: aws.Int64(1),
-		VisibilityTimeout:   aws.Int64(60),
+		MaxNumberOfMessages: aws.Int64(10),
+		VisibilityTimeout:   aws.Int64(visibilityTimeout),
 	})
 
 	return item, err
 }
 
+// ReleaseMessage makes the received message visible to the others again
+func ReleaseMessage(svc *sqs.SQS, queueName string, handle string) error {
+	urlResult, err := svc.GetQueueUrl(&sqs.GetQueueUrlInput{
+		QueueName: &queueName,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = svc.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          urlResult.QueueUrl,
+		ReceiptHandle:     &handle,
+		VisibilityTimeout: aws.Int64(0),
+	})
+
+	return err
+}
+
 // SendMessage sends a message to the queue
 func SendMessage(svc *sqs.SQS, queueName string, message string) error {
+	return SendDelayedMessage(svc, queueName, message, 1)
+}
+
+// SendDelayedMessage sends a message which becomes visible after the delay
+func SendDelayedMessage(svc *sqs.SQS, queueName string, message string, delaySeconds int64) error {
 	urlResult, err := svc.GetQueueUrl(&sqs.GetQueueUrlInput{
 		QueueName: &queueName,
 	})
@@ -41,7 +59,7 @@ func SendMessage(svc *sqs.SQS, queueName string, message string) error {
 	}
 
 	_, err = svc.SendMessage(&sqs.SendMessageInput{
-		DelaySeconds: aws.Int64(1),
+		DelaySeconds: aws.Int64(delaySeconds),
 		MessageBody:  &message,
 		QueueUrl:     urlResult.QueueUrl,
 	})
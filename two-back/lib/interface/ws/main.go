@@ -1,25 +1,81 @@
 package ws
 
 import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
 )
 
+// Notifier sends messages to the websocket connections
+type Notifier interface {
+	// Send sends a message to the specified user
+	Send(connectionID string, message []byte) error
+	// Broadcast sends a message to the specified users
+	Broadcast(connectionIDs []string, message []byte) error
+	// Disconnect disconnects the connection to the specified user
+	Disconnect(connectionID string) error
+}
+
+// NotifierFactory returns the Notifier for the endpoint of the websocket api
+type NotifierFactory func(endpoint string) Notifier
+
+// Endpoint returns the endpoint of the websocket api the request came from
+func Endpoint(domainName string, stage string) string {
+	return fmt.Sprintf("https://%s/%s", domainName, stage)
+}
+
+// APIGatewayNotifier is the Notifier using the api gateway management api
+type APIGatewayNotifier struct {
+	svc *apigatewaymanagementapi.ApiGatewayManagementApi
+}
+
+// NewAPIGatewayNotifier returns the Notifier with its own client for the endpoint
+func NewAPIGatewayNotifier(p client.ConfigProvider, endpoint string) *APIGatewayNotifier {
+	svc := apigatewaymanagementapi.New(p, aws.NewConfig().WithEndpoint(endpoint))
+	return &APIGatewayNotifier{svc: svc}
+}
+
+// NewAPIGatewayFactory returns the NotifierFactory creating APIGatewayNotifier
+func NewAPIGatewayFactory(p client.ConfigProvider) NotifierFactory {
+	return func(endpoint string) Notifier {
+		return NewAPIGatewayNotifier(p, endpoint)
+	}
+}
+
 // Disconnect disconnects the connection to the specified user
-func Disconnect(svc *apigatewaymanagementapi.ApiGatewayManagementApi, endpoint string, connectionID string) {
-	svc.Endpoint = endpoint
-	svc.DeleteConnection(&apigatewaymanagementapi.DeleteConnectionInput{
-		ConnectionId: &connectionID,
+func (n *APIGatewayNotifier) Disconnect(connectionID string) error {
+	_, err := n.svc.DeleteConnection(&apigatewaymanagementapi.DeleteConnectionInput{
+		ConnectionId: aws.String(connectionID),
 	})
+	return err
 }
 
-// Send send a message to the specified users
-func Send(svc *apigatewaymanagementapi.ApiGatewayManagementApi, endpoint string, connectionIDs []string, message []byte) {
-	svc.Endpoint = endpoint
+// Send sends a message to the specified user
+func (n *APIGatewayNotifier) Send(connectionID string, message []byte) error {
+	_, err := n.svc.PostToConnection(&apigatewaymanagementapi.PostToConnectionInput{
+		ConnectionId: aws.String(connectionID),
+		Data:         message,
+	})
+	return err
+}
+
+// Broadcast sends a message to the specified users.
+// It tries every user and returns the first error.
+func (n *APIGatewayNotifier) Broadcast(connectionIDs []string, message []byte) error {
+	return Broadcast(n, connectionIDs, message)
+}
 
+// Broadcast sends a message to the specified users one by one with n.Send.
+// It tries every user and returns the first error.
+func Broadcast(n Notifier, connectionIDs []string, message []byte) error {
+	var firstErr error
 	for _, id := range connectionIDs {
-		svc.PostToConnection(&apigatewaymanagementapi.PostToConnectionInput{
-			ConnectionId: &id,
-			Data:         message,
-		})
+		err := n.Send(id, message)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
+	return firstErr
 }
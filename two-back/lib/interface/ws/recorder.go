@@ -0,0 +1,76 @@
+package ws
+
+import (
+	"sync"
+)
+
+// Recorder is the Notifier that records every outgoing frame in memory
+type Recorder struct {
+	mu           sync.Mutex
+	frames       map[string][][]byte
+	disconnected map[string]bool
+}
+
+// NewRecorder returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		frames:       map[string][][]byte{},
+		disconnected: map[string]bool{},
+	}
+}
+
+// Factory returns the NotifierFactory which always returns the recorder
+func (r *Recorder) Factory() NotifierFactory {
+	return func(endpoint string) Notifier {
+		return r
+	}
+}
+
+// Send records a message to the specified user
+func (r *Recorder) Send(connectionID string, message []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	frame := append([]byte(nil), message...)
+	r.frames[connectionID] = append(r.frames[connectionID], frame)
+	return nil
+}
+
+// Broadcast records a message to the specified users
+func (r *Recorder) Broadcast(connectionIDs []string, message []byte) error {
+	return Broadcast(r, connectionIDs, message)
+}
+
+// Disconnect records that the connection to the specified user was closed
+func (r *Recorder) Disconnect(connectionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.disconnected[connectionID] = true
+	return nil
+}
+
+// Frames returns the frames sent to the specified user in order
+func (r *Recorder) Frames(connectionID string) [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([][]byte(nil), r.frames[connectionID]...)
+}
+
+// Disconnected returns whether the connection to the specified user was closed
+func (r *Recorder) Disconnected(connectionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.disconnected[connectionID]
+}
+
+// Reset forgets every recorded frame and disconnection
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.frames = map[string][][]byte{}
+	r.disconnected = map[string]bool{}
+}
@@ -0,0 +1,49 @@
+package matchmaker
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/service/sqs"
+	myqueue "github.com/uu64/two-apps/two-back/lib/interface/sqs"
+)
+
+// Announcement tells that the room has got two users
+type Announcement struct {
+	RoomID string `json:"roomId"`
+	// Endpoint is the websocket api the users are connected to
+	Endpoint string `json:"endpoint"`
+}
+
+// Announcer passes the matched room to whom starts the game
+type Announcer interface {
+	Announce(a Announcement) error
+}
+
+// AnnouncerFunc is the Announcer calling the function
+type AnnouncerFunc func(a Announcement) error
+
+// Announce calls the function with the announcement
+func (f AnnouncerFunc) Announce(a Announcement) error {
+	return f(a)
+}
+
+// SQSAnnouncer is the Announcer sending the announcement to the queue.
+// The message is delayed so that the new connection is ready to receive.
+type SQSAnnouncer struct {
+	svc       *sqs.SQS
+	queueName string
+}
+
+// NewSQSAnnouncer returns the Announcer using the queue
+func NewSQSAnnouncer(svc *sqs.SQS, queueName string) *SQSAnnouncer {
+	return &SQSAnnouncer{svc: svc, queueName: queueName}
+}
+
+// Announce sends the announcement to the queue as json
+func (a *SQSAnnouncer) Announce(announcement Announcement) error {
+	data, err := json.Marshal(&announcement)
+	if err != nil {
+		return err
+	}
+	return myqueue.SendMessage(a.svc, a.queueName, string(data))
+}
@@ -0,0 +1,74 @@
+package matchmaker
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ticket is the room waiting in the queue
+type Ticket struct {
+	RoomID string `json:"roomId"`
+	// Level is the level the creator of the room wants, or 0 for any level
+	Level      int       `json:"level"`
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+}
+
+// Brackets divides the levels into the ranges matched with each other
+type Brackets struct {
+	// Bounds are the highest levels of the brackets in ascending order.
+	// The levels above the last bound make the last bracket.
+	Bounds []int
+	// CrossAfter is how long a room waits until it accepts any level.
+	// The room never crosses the brackets when it is zero.
+	CrossAfter time.Duration
+}
+
+// DefaultBrackets are the brackets of the matchmakers
+var DefaultBrackets = Brackets{
+	Bounds:     []int{3, 6, 10},
+	CrossAfter: 30 * time.Second,
+}
+
+// Bracket returns the index of the bracket of the level
+func (b Brackets) Bracket(level int) int {
+	for i, bound := range b.Bounds {
+		if level <= bound {
+			return i
+		}
+	}
+	return len(b.Bounds)
+}
+
+// Matches returns whether the room of the ticket is in the bracket of the level,
+// or either of them takes any level
+func (b Brackets) Matches(t Ticket, level int) bool {
+	if t.Level == 0 || level == 0 {
+		return true
+	}
+	return b.Bracket(t.Level) == b.Bracket(level)
+}
+
+// Accepts returns whether the user of the level can join the room of the ticket
+func (b Brackets) Accepts(t Ticket, level int, now time.Time) bool {
+	if b.Matches(t, level) {
+		return true
+	}
+	return b.CrossAfter > 0 && now.Sub(t.EnqueuedAt) >= b.CrossAfter
+}
+
+// ParseBounds parses the comma separated highest levels of the brackets
+func ParseBounds(value string) ([]int, error) {
+	var bounds []int
+	for _, field := range strings.Split(value, ",") {
+		if field == "" {
+			continue
+		}
+		bound, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
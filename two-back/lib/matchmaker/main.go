@@ -0,0 +1,140 @@
+package matchmaker
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sqs"
+	myqueue "github.com/uu64/two-apps/two-back/lib/interface/sqs"
+)
+
+// Matchmaker is the queue of the rooms waiting for a new challenger
+type Matchmaker interface {
+	// Enqueue puts the room into the queue.
+	// level is the level the creator wants, or 0 for any level.
+	Enqueue(roomID string, level int) error
+	// TryMatch takes the oldest room of the bracket of the level,
+	// or the oldest room of the other brackets the user of the level can join.
+	// ok is false when there is no such room waiting.
+	TryMatch(level int) (roomID string, ticket string, ok bool, err error)
+	// Confirm removes the room taken by TryMatch from the queue
+	Confirm(ticket string) error
+	// Release puts the room taken by TryMatch back for the others
+	Release(ticket string) error
+	// Cancel withdraws the room from the queue
+	Cancel(roomID string) error
+	// CrossAfter returns how long a room waits until it accepts any level,
+	// or 0 when the rooms never cross the brackets
+	CrossAfter() time.Duration
+}
+
+// SQSMatchmaker is the Matchmaker backed by the sqs queue
+type SQSMatchmaker struct {
+	svc       *sqs.SQS
+	queueName string
+	// Brackets decides which rooms the user can join
+	Brackets Brackets
+}
+
+// NewSQSMatchmaker returns the Matchmaker using the queue
+func NewSQSMatchmaker(svc *sqs.SQS, queueName string) *SQSMatchmaker {
+	return &SQSMatchmaker{svc: svc, queueName: queueName, Brackets: DefaultBrackets}
+}
+
+// parseTicket decodes the message body.
+// A body which is not json is the room-id of any level.
+func parseTicket(body string) Ticket {
+	var t Ticket
+	if err := json.Unmarshal([]byte(body), &t); err != nil {
+		return Ticket{RoomID: body}
+	}
+	return t
+}
+
+// Enqueue sends the ticket of the room to the queue
+func (m *SQSMatchmaker) Enqueue(roomID string, level int) error {
+	data, err := json.Marshal(&Ticket{
+		RoomID:     roomID,
+		Level:      level,
+		EnqueuedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return myqueue.SendMessage(m.svc, m.queueName, string(data))
+}
+
+// TryMatch receives a batch from the queue and takes the first room
+// of the bracket of the level, or the first room of the other brackets
+// the user of the level can join. The others are released at once.
+// The receipt handle of the message is returned as the ticket.
+func (m *SQSMatchmaker) TryMatch(level int) (string, string, bool, error) {
+	output, err := myqueue.PeekMessages(m.svc, m.queueName, 60)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	now := time.Now()
+	taken := -1
+	for i, message := range output.Messages {
+		t := parseTicket(*message.Body)
+		if m.Brackets.Matches(t, level) {
+			taken = i
+			break
+		}
+		if taken < 0 && m.Brackets.Accepts(t, level, now) {
+			taken = i
+		}
+	}
+
+	roomID, handle, ok := "", "", false
+	for i, message := range output.Messages {
+		if i == taken {
+			roomID, handle, ok = parseTicket(*message.Body).RoomID, *message.ReceiptHandle, true
+			continue
+		}
+
+		err = myqueue.ReleaseMessage(m.svc, m.queueName, *message.ReceiptHandle)
+		if err != nil {
+			return "", "", false, err
+		}
+	}
+	return roomID, handle, ok, nil
+}
+
+// Confirm deletes the message from the queue
+func (m *SQSMatchmaker) Confirm(ticket string) error {
+	return myqueue.DeleteMessage(m.svc, m.queueName, ticket)
+}
+
+// CrossAfter returns how long a room waits until it accepts any level
+func (m *SQSMatchmaker) CrossAfter() time.Duration {
+	return m.Brackets.CrossAfter
+}
+
+// Release makes the message visible to the others again
+func (m *SQSMatchmaker) Release(ticket string) error {
+	return myqueue.ReleaseMessage(m.svc, m.queueName, ticket)
+}
+
+// Cancel deletes the message of the room if it is found in a batch of the queue.
+// sqs cannot delete a message by its body, so this is the best effort
+// and the joiner skips the ticket of the room which is no longer waiting.
+func (m *SQSMatchmaker) Cancel(roomID string) error {
+	output, err := myqueue.PeekMessages(m.svc, m.queueName, 5)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range output.Messages {
+		if parseTicket(*message.Body).RoomID == roomID {
+			err = myqueue.DeleteMessage(m.svc, m.queueName, *message.ReceiptHandle)
+		} else {
+			err = myqueue.ReleaseMessage(m.svc, m.queueName, *message.ReceiptHandle)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package matchmaker
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	Ticket
+	ticket string
+	// seq is the order the room is enqueued in
+	seq int
+}
+
+// MemoryMatchmaker is the Matchmaker that keeps a FIFO queue in memory
+type MemoryMatchmaker struct {
+	// Brackets decides which rooms the user can join
+	Brackets Brackets
+
+	mu       sync.Mutex
+	queue    []entry
+	inFlight map[string]entry
+	next     int
+	now      func() time.Time
+}
+
+// NewMemoryMatchmaker returns an empty MemoryMatchmaker
+func NewMemoryMatchmaker() *MemoryMatchmaker {
+	return &MemoryMatchmaker{
+		Brackets: DefaultBrackets,
+		inFlight: map[string]entry{},
+		now:      time.Now,
+	}
+}
+
+// SetClock replaces the clock telling how long the rooms have waited
+func (m *MemoryMatchmaker) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = now
+}
+
+// Enqueue puts the room at the end of the queue
+func (m *MemoryMatchmaker) Enqueue(roomID string, level int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.next++
+	m.queue = append(m.queue, entry{
+		Ticket: Ticket{RoomID: roomID, Level: level, EnqueuedAt: m.now()},
+		ticket: strconv.Itoa(m.next),
+		seq:    m.next,
+	})
+	return nil
+}
+
+// TryMatch takes the first room in the queue of the bracket of the level,
+// or the first room of the other brackets the user of the level can join.
+// The room is kept as in flight until it is confirmed or canceled.
+func (m *MemoryMatchmaker) TryMatch(level int) (string, string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	taken := -1
+	for i, e := range m.queue {
+		if m.Brackets.Matches(e.Ticket, level) {
+			taken = i
+			break
+		}
+		if taken < 0 && m.Brackets.Accepts(e.Ticket, level, now) {
+			taken = i
+		}
+	}
+	if taken < 0 {
+		return "", "", false, nil
+	}
+
+	e := m.queue[taken]
+	m.queue = append(m.queue[:taken:taken], m.queue[taken+1:]...)
+	m.inFlight[e.ticket] = e
+	return e.RoomID, e.ticket, true, nil
+}
+
+// CrossAfter returns how long a room waits until it accepts any level
+func (m *MemoryMatchmaker) CrossAfter() time.Duration {
+	return m.Brackets.CrossAfter
+}
+
+// Confirm forgets the room taken by TryMatch
+func (m *MemoryMatchmaker) Confirm(ticket string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.inFlight, ticket)
+	return nil
+}
+
+// Release puts the room taken by TryMatch back in its place of the queue
+func (m *MemoryMatchmaker) Release(ticket string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.inFlight[ticket]
+	if !ok {
+		return nil
+	}
+	delete(m.inFlight, ticket)
+
+	i := 0
+	for i < len(m.queue) && m.queue[i].seq < e.seq {
+		i++
+	}
+	m.queue = append(m.queue[:i:i], append([]entry{e}, m.queue[i:]...)...)
+	return nil
+}
+
+// Cancel removes the room from the queue
+func (m *MemoryMatchmaker) Cancel(roomID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queue := m.queue[:0]
+	for _, e := range m.queue {
+		if e.RoomID != roomID {
+			queue = append(queue, e)
+		}
+	}
+	m.queue = queue
+
+	for ticket, e := range m.inFlight {
+		if e.RoomID == roomID {
+			delete(m.inFlight, ticket)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of the rooms waiting in the queue
+func (m *MemoryMatchmaker) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.queue)
+}
@@ -0,0 +1,26 @@
+package rating
+
+import "math"
+
+// DefaultRating is the rating of the player who has never played
+var DefaultRating int = 1500
+
+// K is the most the rating changes in a match
+var K float64 = 32
+
+// scores of the results of a match
+const (
+	Win  float64 = 1
+	Draw float64 = 0.5
+	Loss float64 = 0
+)
+
+// Expected returns the score the player is expected to get against the opponent
+func Expected(rating int, opponent int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opponent-rating)/400))
+}
+
+// Update returns the new rating of the player who got the score against the opponent
+func Update(rating int, opponent int, score float64) int {
+	return rating + int(math.Round(K*(score-Expected(rating, opponent))))
+}
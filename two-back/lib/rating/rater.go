@@ -0,0 +1,150 @@
+package rating
+
+import (
+	"fmt"
+
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/repository/players"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+)
+
+// maxRateAttempts is the number of times the rating of a player is written
+// when another match rates the player meanwhile
+var maxRateAttempts int = 3
+
+// Rater updates the ratings of the players of the finished matches
+type Rater struct {
+	Players players.PlayerStore
+	Users   users.UserStore
+}
+
+// result is the rating of a player before and after a match
+type result struct {
+	playerID string
+	rating   int
+	// games is the number of the matches the rating was read with
+	games   int
+	updated int
+}
+
+// scores returns the score of each player of the match,
+// or nil unless the match has a result to rate
+func scores(match *game.Match) []float64 {
+	switch {
+	case match.Winner == match.Players[0]:
+		return []float64{Win, Loss}
+	case match.Winner == match.Players[1]:
+		return []float64{Loss, Win}
+	case match.Reason == game.ReasonTimeUp:
+		return []float64{Draw, Draw}
+	}
+	return nil
+}
+
+// read returns the current rating of the player
+func (r *Rater) read(playerID string) (result, error) {
+	player, err := r.Players.Get(playerID)
+	if err == players.ErrPlayerNotFound {
+		return result{playerID: playerID, rating: DefaultRating}, nil
+	}
+	return result{playerID: playerID, rating: player.Rating, games: player.Games}, err
+}
+
+// write updates the rating of the player by the score against the opponent.
+// The rating is read again when another match has updated it meanwhile.
+func (r *Rater) write(res result, opponent int, score float64) (result, error) {
+	var err error
+	for i := 0; i < maxRateAttempts; i++ {
+		res.updated = Update(res.rating, opponent, score)
+		err = r.Players.UpdateRating(res.playerID, res.games, res.updated)
+		if err != players.ErrRatingConflict {
+			return res, err
+		}
+
+		res, err = r.read(res.playerID)
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, players.ErrRatingConflict
+}
+
+// Rate updates the ratings of both players of the match which is over,
+// and returns the events with the new rating and its change for each player.
+// The match won by the forfeit is rated as well as the one solved or drawn.
+// The match with an anonymous player is not rated.
+func (r *Rater) Rate(match *game.Match, events []game.Event) ([]game.Event, error) {
+	scores := scores(match)
+	if !match.Over || scores == nil {
+		return events, nil
+	}
+
+	playerIDs := map[string]string{}
+	for _, player := range match.Players {
+		playerID, err := r.Users.PlayerID(player)
+		if err != nil {
+			return events, err
+		}
+		if playerID == "" {
+			return events, nil
+		}
+		playerIDs[player] = playerID
+	}
+
+	read := map[string]result{}
+	for _, player := range match.Players {
+		var err error
+		read[player], err = r.read(playerIDs[player])
+		if err != nil {
+			return events, err
+		}
+	}
+
+	// each player is rated against the rating the opponent had before the match
+	results := map[string]result{}
+	for i, player := range match.Players {
+		opponent := match.Players[1-i]
+		res, err := r.write(read[player], read[opponent].rating, scores[i])
+		if err != nil {
+			return events, err
+		}
+		results[player] = res
+	}
+
+	return withRatings(events, results), nil
+}
+
+// TryRate rates the match like Rate, but the result is told
+// without the ratings when they cannot be updated
+func (r *Rater) TryRate(match *game.Match, events []game.Event) []game.Event {
+	rated, err := r.Rate(match, events)
+	if err != nil {
+		fmt.Println(err)
+		return events
+	}
+	return rated
+}
+
+// withRatings splits the results of the match into the event for each player
+// and adds the rating of the player
+func withRatings(events []game.Event, results map[string]result) []game.Event {
+	var rated []game.Event
+	for _, e := range events {
+		if e.Message != "YOU_WIN" && e.Message != "YOU_LOSE" && e.Message != "TIME_UP" {
+			rated = append(rated, e)
+			continue
+		}
+
+		for _, to := range e.To {
+			res, ok := results[to]
+			e := e
+			e.To = []string{to}
+			if ok {
+				e.Rating = res.updated
+				e.RatingChange = res.updated - res.rating
+			}
+			rated = append(rated, e)
+		}
+	}
+	return rated
+}
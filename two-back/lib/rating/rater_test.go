@@ -0,0 +1,65 @@
+package rating
+
+import (
+	"testing"
+
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/repository/players"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+)
+
+// racingStore rates the player in another match
+// right before the first update of the player
+type racingStore struct {
+	*players.MemoryStore
+	player string
+	rating int
+	raced  bool
+}
+
+func (s *racingStore) UpdateRating(id string, games int, rating int) error {
+	if id == s.player && !s.raced {
+		s.raced = true
+		if err := s.MemoryStore.UpdateRating(id, games, s.rating); err != nil {
+			return err
+		}
+	}
+	return s.MemoryStore.UpdateRating(id, games, rating)
+}
+
+func TestRateAfterConflict(t *testing.T) {
+	store := &racingStore{MemoryStore: players.NewMemoryStore(), player: "alice", rating: 1600}
+	userStore := users.NewMemoryStore()
+	userStore.Create("conn1", "alice", "room", 0)
+	userStore.Create("conn2", "bob", "room", 0)
+	rater := &Rater{Players: store, Users: userStore}
+
+	match := &game.Match{
+		Players: []string{"conn1", "conn2"},
+		Winner:  "conn1",
+		Reason:  game.ReasonSolved,
+		Started: true,
+		Over:    true,
+	}
+	events, err := rater.Rate(match, []game.Event{
+		{To: []string{"conn1"}, Message: "YOU_WIN"},
+		{To: []string{"conn2"}, Message: "YOU_LOSE"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// alice is rated from the rating the other match has written
+	want := Update(1600, DefaultRating, Win)
+	alice, _ := store.Get("alice")
+	if alice.Rating != want || alice.Games != 2 {
+		t.Errorf("alice = %+v, want rating %d after 2 games", alice, want)
+	}
+	if events[0].Rating != want || events[0].RatingChange != want-1600 {
+		t.Errorf("event to alice = %+v, want rating %d", events[0], want)
+	}
+	bob, _ := store.Get("bob")
+	if bob.Rating != Update(DefaultRating, DefaultRating, Loss) || bob.Games != 1 {
+		t.Errorf("bob = %+v, want rated against the rating alice had before", bob)
+	}
+}
@@ -0,0 +1,115 @@
+package players
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+var playerTableName string = "players"
+
+// ErrPlayerNotFound is returned when the player has never been rated
+var ErrPlayerNotFound = errors.New("player is not exist")
+
+// ErrRatingConflict is returned when another match has rated the player meanwhile
+var ErrRatingConflict = errors.New("rating is updated meanwhile")
+
+// Player is defintion of the players table item,
+// which is kept across the connections unlike the users
+type Player struct {
+	PlayerID string
+	Rating   int
+	// Games is the number of the rated matches,
+	// which is also the version of the rating
+	Games int
+}
+
+// PlayerStore is the storage of the players
+type PlayerStore interface {
+	// Get returns the player with the id
+	Get(id string) (Player, error)
+	// UpdateRating sets the rating of the player after a match.
+	// games is the number of the matches the rating was read with,
+	// or 0 for the player who has never been rated.
+	// The player is created unless it exists.
+	// It returns ErrRatingConflict when the player has played another match since.
+	UpdateRating(id string, games int, rating int) error
+}
+
+// DynamoStore is the PlayerStore backed by the players table
+type DynamoStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewDynamoStore returns the PlayerStore using the dynamodb client
+func NewDynamoStore(svc *dynamodb.DynamoDB) *DynamoStore {
+	return &DynamoStore{svc: svc}
+}
+
+// Get returns the player with the id
+func (s *DynamoStore) Get(id string) (Player, error) {
+	player := Player{}
+
+	result, err := s.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(playerTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"PlayerID": {
+				S: aws.String(id),
+			},
+		},
+	})
+	if err != nil {
+		return player, err
+	}
+
+	if result.Item == nil {
+		return player, ErrPlayerNotFound
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &player)
+	return player, err
+}
+
+// UpdateRating sets the rating of the player and counts the match
+// unless the player has played another match since the rating was read
+func (s *DynamoStore) UpdateRating(id string, games int, rating int) error {
+	_, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":r": {
+				N: aws.String(strconv.Itoa(rating)),
+			},
+			":one": {
+				N: aws.String("1"),
+			},
+			":g": {
+				N: aws.String(strconv.Itoa(games)),
+			},
+		},
+		TableName: aws.String(playerTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"PlayerID": {
+				S: aws.String(id),
+			},
+		},
+		ReturnValues:     aws.String("UPDATED_NEW"),
+		UpdateExpression: aws.String("set Rating = :r add Games :one"),
+		// a new player has no games, since every rated match counts one
+		ConditionExpression: aws.String("attribute_not_exists(Games) OR Games = :g"),
+	})
+
+	if isConditionalCheckFailed(err) {
+		return ErrRatingConflict
+	}
+	return err
+}
+
+func isConditionalCheckFailed(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+	}
+	return false
+}
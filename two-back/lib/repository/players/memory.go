@@ -0,0 +1,45 @@
+package players
+
+import (
+	"sync"
+)
+
+// MemoryStore is the PlayerStore that keeps the players in memory
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]Player
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: map[string]Player{}}
+}
+
+// Get returns the player with the id
+func (s *MemoryStore) Get(id string) (Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	player, ok := s.items[id]
+	if !ok {
+		return Player{}, ErrPlayerNotFound
+	}
+	return player, nil
+}
+
+// UpdateRating sets the rating of the player and counts the match
+// unless the player has played another match since the rating was read
+func (s *MemoryStore) UpdateRating(id string, games int, rating int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	player := s.items[id]
+	if player.Games != games {
+		return ErrRatingConflict
+	}
+	player.PlayerID = id
+	player.Rating = rating
+	player.Games++
+	s.items[id] = player
+	return nil
+}
@@ -0,0 +1,197 @@
+package results
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+var resultTableName string = "results"
+
+// solveTimeIndexName is the local secondary index of the results by the solve time
+var solveTimeIndexName string = "SolveTimeIndex"
+
+// ErrResultNotFound is returned when the player has not started the challenge
+var ErrResultNotFound = errors.New("result is not exist")
+
+// ErrAlreadySolved is returned when the player has already solved the challenge
+var ErrAlreadySolved = errors.New("result is already solved")
+
+// Result is defintion of the results table item,
+// the daily challenge a player has played
+type Result struct {
+	Date     string
+	PlayerID string
+	// StartedAt is the unix time in milliseconds the player got the problem
+	StartedAt int64
+	Solved    bool
+	// SolveTime is the milliseconds the player took to solve the problem.
+	// It is left out until solved, so only the solved results are indexed.
+	SolveTime int64 `dynamodbav:",omitempty"`
+}
+
+// ResultStore is the storage of the results of the daily challenges
+type ResultStore interface {
+	// Start records the time the player got the problem of the date.
+	// The first time is kept when the player gets it again.
+	Start(date string, playerID string, at time.Time) error
+	// Solve records the time the player solved the problem of the date
+	// and returns the result.
+	// It returns ErrResultNotFound unless the player has started,
+	// and ErrAlreadySolved when the player has solved it.
+	Solve(date string, playerID string, at time.Time) (Result, error)
+	// Rank returns the place of the solve time among the results of the date
+	Rank(date string, solveTime int64) (int, error)
+}
+
+// millis returns the unix time in milliseconds
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// DynamoStore is the ResultStore backed by the results table
+type DynamoStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewDynamoStore returns the ResultStore using the dynamodb client
+func NewDynamoStore(svc *dynamodb.DynamoDB) *DynamoStore {
+	return &DynamoStore{svc: svc}
+}
+
+func key(date string, playerID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"Date": {
+			S: aws.String(date),
+		},
+		"PlayerID": {
+			S: aws.String(playerID),
+		},
+	}
+}
+
+func (s *DynamoStore) getItem(date string, playerID string) (Result, error) {
+	result := Result{}
+
+	item, err := s.svc.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(resultTableName),
+		Key:       key(date, playerID),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if item.Item == nil {
+		return result, ErrResultNotFound
+	}
+
+	err = dynamodbattribute.UnmarshalMap(item.Item, &result)
+	return result, err
+}
+
+// Start records the time the player got the problem of the date
+func (s *DynamoStore) Start(date string, playerID string, at time.Time) error {
+	av, err := dynamodbattribute.MarshalMap(Result{
+		Date:      date,
+		PlayerID:  playerID,
+		StartedAt: millis(at),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.svc.PutItem(&dynamodb.PutItemInput{
+		Item:                av,
+		TableName:           aws.String(resultTableName),
+		ConditionExpression: aws.String("attribute_not_exists(PlayerID)"),
+	})
+	if isConditionalCheckFailed(err) {
+		// the player has started already
+		return nil
+	}
+	return err
+}
+
+// Solve records the time the player solved the problem of the date.
+// The solve time is computed by the table, so only the first answer counts.
+func (s *DynamoStore) Solve(date string, playerID string, at time.Time) (Result, error) {
+	result := Result{}
+
+	item, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":at": {
+				N: aws.String(strconv.FormatInt(millis(at), 10)),
+			},
+			":s": {
+				BOOL: aws.Bool(true),
+			},
+		},
+		TableName:           aws.String(resultTableName),
+		Key:                 key(date, playerID),
+		ReturnValues:        aws.String("ALL_NEW"),
+		UpdateExpression:    aws.String("set Solved = :s, SolveTime = :at - StartedAt"),
+		ConditionExpression: aws.String("attribute_exists(StartedAt) and attribute_not_exists(SolveTime)"),
+	})
+	if isConditionalCheckFailed(err) {
+		if _, err := s.getItem(date, playerID); err != nil {
+			return result, err
+		}
+		return result, ErrAlreadySolved
+	}
+	if err != nil {
+		return result, err
+	}
+
+	err = dynamodbattribute.UnmarshalMap(item.Attributes, &result)
+	return result, err
+}
+
+// Rank returns the place of the solve time among the results of the date.
+// The players with the same time share the place.
+func (s *DynamoStore) Rank(date string, solveTime int64) (int, error) {
+	faster := 0
+
+	input := &dynamodb.QueryInput{
+		ExpressionAttributeNames: map[string]*string{
+			"#d": aws.String("Date"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":d": {
+				S: aws.String(date),
+			},
+			":t": {
+				N: aws.String(strconv.FormatInt(solveTime, 10)),
+			},
+		},
+		TableName:              aws.String(resultTableName),
+		IndexName:              aws.String(solveTimeIndexName),
+		KeyConditionExpression: aws.String("#d = :d and SolveTime < :t"),
+		Select:                 aws.String(dynamodb.SelectCount),
+	}
+	for {
+		output, err := s.svc.Query(input)
+		if err != nil {
+			return 0, err
+		}
+		faster += int(aws.Int64Value(output.Count))
+
+		if output.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	return faster + 1, nil
+}
+
+func isConditionalCheckFailed(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package results
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is the ResultStore that keeps the results in memory
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]map[string]Result
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: map[string]map[string]Result{}}
+}
+
+// Start records the time the player got the problem of the date
+func (s *MemoryStore) Start(date string, playerID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.items[date] == nil {
+		s.items[date] = map[string]Result{}
+	}
+	if _, ok := s.items[date][playerID]; ok {
+		// the player has started already
+		return nil
+	}
+
+	s.items[date][playerID] = Result{
+		Date:      date,
+		PlayerID:  playerID,
+		StartedAt: millis(at),
+	}
+	return nil
+}
+
+// Solve records the time the player solved the problem of the date
+func (s *MemoryStore) Solve(date string, playerID string, at time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, ok := s.items[date][playerID]
+	if !ok {
+		return Result{}, ErrResultNotFound
+	}
+	if result.Solved {
+		return Result{}, ErrAlreadySolved
+	}
+
+	result.Solved = true
+	result.SolveTime = millis(at) - result.StartedAt
+	s.items[date][playerID] = result
+	return result, nil
+}
+
+// Rank returns the place of the solve time among the results of the date
+func (s *MemoryStore) Rank(date string, solveTime int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rank := 1
+	for _, result := range s.items[date] {
+		if result.Solved && result.SolveTime < solveTime {
+			rank++
+		}
+	}
+	return rank, nil
+}
@@ -2,37 +2,131 @@ package rooms
 
 import (
 	"errors"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/google/uuid"
+	"github.com/uu64/two-apps/two-back/lib/game"
 )
 
 var roomTableName string = "rooms"
 
+// roomTTL is how long a room is kept after it is created
+var roomTTL time.Duration = 24 * time.Hour
+
 // Room is defintion of the rooms table item
 type Room struct {
-	RoomID  string
-	Status  string
-	User1ID string
-	User2ID string
-	Problem []int
+	RoomID    string
+	Status    RoomStatus
+	User1ID   string
+	User2ID   string
+	Problem   []int
+	Seed      int64
+	Spec      game.Spec
+	TimeLimit int
+	// StartedAt is the unix time in milliseconds the game has started
+	StartedAt int64
+	WinnerID  string
+	EndReason string
+	// ReadyUsers is the users who have confirmed the match
+	ReadyUsers []string `dynamodbav:",stringset,omitempty"`
+	// ExpiresAt is the unix time the room is deleted by the ttl of the table
+	ExpiresAt int64
 }
 
-// RoomStatusWaiting is status of the rooms table item
-var RoomStatusWaiting string = "WAITING"
+// ErrRoomNotFound is returned when the room does not exist
+var ErrRoomNotFound = errors.New("room is not exist")
+
+// ErrRoomNotWaiting is returned when the room cannot accept a new challenger
+var ErrRoomNotWaiting = errors.New("room is not waiting")
+
+// Match returns the match played in the room
+func (r Room) Match() *game.Match {
+	return &game.Match{
+		Players:   []string{r.User1ID, r.User2ID},
+		Problem:   r.Problem,
+		Seed:      r.Seed,
+		Spec:      r.Spec,
+		TimeLimit: r.TimeLimit,
+		StartedAt: time.Unix(0, r.StartedAt*int64(time.Millisecond)),
+		Winner:    r.WinnerID,
+		Reason:    r.EndReason,
+		Started: r.Status == RoomStatusPlaying || r.Status == RoomStatusFinished ||
+			r.Problem != nil,
+		Over: r.Status.Terminal(),
+	}
+}
 
-// RoomStatusPreparing is status of the rooms table item
-var RoomStatusPreparing string = "PREPARING"
+// IsReady returns whether the user has confirmed the match
+func (r Room) IsReady(userID string) bool {
+	for _, id := range r.ReadyUsers {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
 
-// RoomStatusPlaying is status of the rooms table item
-var RoomStatusPlaying string = "PLAYING"
+// RoomStore is the storage of the rooms
+type RoomStore interface {
+	// Get returns the room with the id
+	Get(id string) (Room, error)
+	// Create creates a room and returns the room-id
+	Create(userID string) (string, error)
+	// AddUser adds the user to the room.
+	// It returns ErrRoomNotWaiting unless the room is waiting for a challenger.
+	AddUser(id string, userID string) error
+	// StartGame sets a problem, the seed it is created from,
+	// its spec, its time limit in seconds and when it has started to the room.
+	// It returns ErrInvalidTransition unless the room is preparing.
+	StartGame(id string, problem []int, seed int64, spec game.Spec, timeLimit int, startedAt time.Time) error
+	// Ready marks the user in the room as ready and returns the updated room.
+	// It returns ErrInvalidTransition unless the room is preparing.
+	Ready(id string, userID string) (Room, error)
+	// Transition moves the room from the status to next.
+	// It returns ErrInvalidTransition when the move is not allowed
+	// or the room is not in the status any more.
+	Transition(id string, from RoomStatus, next RoomStatus) error
+	// Status returns the status of the room
+	Status(id string) (RoomStatus, error)
+	// Users returns the connection-id of the user in the room
+	Users(id string) ([]string, error)
+	// Problem returns the problem of the room
+	Problem(id string) ([]int, error)
+	// Finish records the result and finishes the game.
+	// winnerID is empty when the game is a draw.
+	// It returns false when the game is not in progress any more or the room is gone.
+	Finish(id string, winnerID string, reason string) (bool, error)
+	// Delete deletes the room with the id
+	Delete(id string) error
+}
+
+// DynamoStore is the RoomStore backed by the rooms table
+type DynamoStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewDynamoStore returns the RoomStore using the dynamodb client
+func NewDynamoStore(svc *dynamodb.DynamoDB) *DynamoStore {
+	return &DynamoStore{svc: svc}
+}
+
+func newRoomID() (string, error) {
+	uuidObj, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	return uuidObj.String(), nil
+}
 
-func getItem(svc *dynamodb.DynamoDB, id string) (Room, error) {
+func (s *DynamoStore) getItem(id string) (Room, error) {
 	room := Room{}
 
-	result, err := svc.GetItem(&dynamodb.GetItemInput{
+	result, err := s.svc.GetItem(&dynamodb.GetItemInput{
 		TableName: aws.String(roomTableName),
 		Key: map[string]*dynamodb.AttributeValue{
 			"RoomID": {
@@ -45,46 +139,49 @@ func getItem(svc *dynamodb.DynamoDB, id string) (Room, error) {
 	}
 
 	if result.Item == nil {
-		return room, errors.New("room is not exist")
+		return room, ErrRoomNotFound
 	}
 
 	err = dynamodbattribute.UnmarshalMap(result.Item, &room)
 	return room, err
 }
 
+// Get returns the room with the id
+func (s *DynamoStore) Get(id string) (Room, error) {
+	return s.getItem(id)
+}
+
 // Users returns the connection-id of the user in the room
-func Users(svc *dynamodb.DynamoDB, id string) ([]string, error) {
-	room, err := getItem(svc, id)
+func (s *DynamoStore) Users(id string) ([]string, error) {
+	room, err := s.getItem(id)
 	return []string{room.User1ID, room.User2ID}, err
 }
 
 // Status returns the status of the room
-func Status(svc *dynamodb.DynamoDB, id string) (string, error) {
-	room, err := getItem(svc, id)
+func (s *DynamoStore) Status(id string) (RoomStatus, error) {
+	room, err := s.getItem(id)
 	return room.Status, err
 }
 
 // Problem returns the problem of the room
-func Problem(svc *dynamodb.DynamoDB, id string) ([]int, error) {
-	room, err := getItem(svc, id)
+func (s *DynamoStore) Problem(id string) ([]int, error) {
+	room, err := s.getItem(id)
 	return room.Problem, err
 }
 
 // Create creates a room and returns the room-id
-func Create(svc *dynamodb.DynamoDB, userID string) (string, error) {
-	var roomID string
-
-	uuidObj, err := uuid.NewRandom()
+func (s *DynamoStore) Create(userID string) (string, error) {
+	roomID, err := newRoomID()
 	if err != nil {
 		return roomID, err
 	}
 
-	roomID = uuidObj.String()
 	item := Room{
-		RoomID:  roomID,
-		Status:  RoomStatusWaiting,
-		User1ID: userID,
-		User2ID: "",
+		RoomID:    roomID,
+		Status:    RoomStatusWaiting,
+		User1ID:   userID,
+		User2ID:   "",
+		ExpiresAt: time.Now().Add(roomTTL).Unix(),
 	}
 
 	av, err := dynamodbattribute.MarshalMap(item)
@@ -92,7 +189,7 @@ func Create(svc *dynamodb.DynamoDB, userID string) (string, error) {
 		return roomID, err
 	}
 
-	_, err = svc.PutItem(&dynamodb.PutItemInput{
+	_, err = s.svc.PutItem(&dynamodb.PutItemInput{
 		Item:      av,
 		TableName: aws.String(roomTableName),
 	})
@@ -104,8 +201,8 @@ func Create(svc *dynamodb.DynamoDB, userID string) (string, error) {
 }
 
 // Delete deletes the room with the id
-func Delete(svc *dynamodb.DynamoDB, id string) error {
-	_, err := svc.DeleteItem(&dynamodb.DeleteItemInput{
+func (s *DynamoStore) Delete(id string) error {
+	_, err := s.svc.DeleteItem(&dynamodb.DeleteItemInput{
 		TableName: aws.String(roomTableName),
 		Key: map[string]*dynamodb.AttributeValue{
 			"RoomID": {
@@ -119,9 +216,15 @@ func Delete(svc *dynamodb.DynamoDB, id string) error {
 	return nil
 }
 
-// AddUser adds the user to the room
-func AddUser(svc *dynamodb.DynamoDB, id string, userID string) error {
-	_, err := svc.UpdateItem(&dynamodb.UpdateItemInput{
+// AddUser adds the user to the room.
+// The room is claimed atomically, so only one challenger can join it.
+func (s *DynamoStore) AddUser(id string, userID string) error {
+	from, ok := RoomStatusPreparing.previous()
+	if !ok {
+		return ErrRoomNotWaiting
+	}
+
+	_, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
 		ExpressionAttributeNames: map[string]*string{
 			"#st": aws.String("Status"),
 		},
@@ -130,7 +233,13 @@ func AddUser(svc *dynamodb.DynamoDB, id string, userID string) error {
 				S: aws.String(userID),
 			},
 			":st": {
-				S: aws.String(RoomStatusPreparing),
+				S: aws.String(string(RoomStatusPreparing)),
+			},
+			":from": {
+				S: aws.String(string(from)),
+			},
+			":null": {
+				S: aws.String("NULL"),
 			},
 		},
 		TableName: aws.String(roomTableName),
@@ -141,8 +250,14 @@ func AddUser(svc *dynamodb.DynamoDB, id string, userID string) error {
 		},
 		ReturnValues:     aws.String("UPDATED_NEW"),
 		UpdateExpression: aws.String("set User2ID = :id, #st = :st"),
+		// an empty User2ID is stored as NULL by dynamodbattribute
+		ConditionExpression: aws.String("#st = :from AND " +
+			"(attribute_not_exists(User2ID) OR attribute_type(User2ID, :null))"),
 	})
 
+	if isConditionalCheckFailed(err) {
+		return ErrRoomNotWaiting
+	}
 	if err != nil {
 		return err
 	}
@@ -150,21 +265,43 @@ func AddUser(svc *dynamodb.DynamoDB, id string, userID string) error {
 	return nil
 }
 
-// StartGame sets a problem to the room
-func StartGame(svc *dynamodb.DynamoDB, id string, problem []int) error {
+// StartGame sets a problem, its seed, its spec, its time limit and its start to the room
+func (s *DynamoStore) StartGame(id string, problem []int, seed int64, spec game.Spec, timeLimit int, startedAt time.Time) error {
+	from, ok := RoomStatusPlaying.previous()
+	if !ok {
+		return ErrInvalidTransition
+	}
+
 	av, err := dynamodbattribute.Marshal(problem)
 	if err != nil {
 		return err
 	}
+	specAv, err := dynamodbattribute.Marshal(spec)
+	if err != nil {
+		return err
+	}
 
-	_, err = svc.UpdateItem(&dynamodb.UpdateItemInput{
+	_, err = s.svc.UpdateItem(&dynamodb.UpdateItemInput{
 		ExpressionAttributeNames: map[string]*string{
 			"#st": aws.String("Status"),
 		},
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":p": av,
+			":sd": {
+				N: aws.String(strconv.FormatInt(seed, 10)),
+			},
+			":s": specAv,
+			":t": {
+				N: aws.String(strconv.Itoa(timeLimit)),
+			},
+			":at": {
+				N: aws.String(strconv.FormatInt(startedAt.UnixNano()/int64(time.Millisecond), 10)),
+			},
 			":st": {
-				S: aws.String(RoomStatusPlaying),
+				S: aws.String(string(RoomStatusPlaying)),
+			},
+			":from": {
+				S: aws.String(string(from)),
 			},
 		},
 		TableName: aws.String(roomTableName),
@@ -173,9 +310,149 @@ func StartGame(svc *dynamodb.DynamoDB, id string, problem []int) error {
 				S: aws.String(id),
 			},
 		},
-		ReturnValues:     aws.String("UPDATED_NEW"),
-		UpdateExpression: aws.String("set Problem = :p, #st = :st"),
+		ReturnValues:        aws.String("UPDATED_NEW"),
+		UpdateExpression:    aws.String("set Problem = :p, Seed = :sd, Spec = :s, TimeLimit = :t, StartedAt = :at, #st = :st"),
+		ConditionExpression: aws.String("#st = :from"),
 	})
 
+	if isConditionalCheckFailed(err) {
+		return ErrInvalidTransition
+	}
 	return err
 }
+
+// Ready marks the user in the room as ready and returns the updated room.
+// The user is added to the set atomically, so the last one sees both users.
+func (s *DynamoStore) Ready(id string, userID string) (Room, error) {
+	room := Room{}
+
+	result, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]*string{
+			"#st": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":u": {
+				SS: []*string{aws.String(userID)},
+			},
+			":id": {
+				S: aws.String(userID),
+			},
+			":preparing": {
+				S: aws.String(string(RoomStatusPreparing)),
+			},
+		},
+		TableName: aws.String(roomTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"RoomID": {
+				S: aws.String(id),
+			},
+		},
+		ReturnValues:     aws.String("ALL_NEW"),
+		UpdateExpression: aws.String("add ReadyUsers :u"),
+		ConditionExpression: aws.String("#st = :preparing AND " +
+			"(User1ID = :id OR User2ID = :id)"),
+	})
+
+	if isConditionalCheckFailed(err) {
+		return room, ErrInvalidTransition
+	}
+	if err != nil {
+		return room, err
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Attributes, &room)
+	return room, err
+}
+
+// Transition moves the room from the status to next
+func (s *DynamoStore) Transition(id string, from RoomStatus, next RoomStatus) error {
+	if !from.CanTransition(next) {
+		return ErrInvalidTransition
+	}
+
+	_, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]*string{
+			"#st": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":from": {
+				S: aws.String(string(from)),
+			},
+			":st": {
+				S: aws.String(string(next)),
+			},
+		},
+		TableName: aws.String(roomTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"RoomID": {
+				S: aws.String(id),
+			},
+		},
+		ReturnValues:        aws.String("UPDATED_NEW"),
+		UpdateExpression:    aws.String("set #st = :st"),
+		ConditionExpression: aws.String("#st = :from"),
+	})
+
+	if isConditionalCheckFailed(err) {
+		return ErrInvalidTransition
+	}
+	return err
+}
+
+// Finish records the result and finishes the game.
+// Only the first call for the room succeeds.
+func (s *DynamoStore) Finish(id string, winnerID string, reason string) (bool, error) {
+	from, ok := RoomStatusFinished.previous()
+	if !ok {
+		return false, nil
+	}
+
+	// an empty string is stored as NULL like dynamodbattribute does
+	winner := &dynamodb.AttributeValue{S: aws.String(winnerID)}
+	if winnerID == "" {
+		winner = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	}
+
+	_, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]*string{
+			"#st": aws.String("Status"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":w": winner,
+			":r": {
+				S: aws.String(reason),
+			},
+			":st": {
+				S: aws.String(string(RoomStatusFinished)),
+			},
+			":from": {
+				S: aws.String(string(from)),
+			},
+		},
+		TableName: aws.String(roomTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"RoomID": {
+				S: aws.String(id),
+			},
+		},
+		ReturnValues:        aws.String("UPDATED_NEW"),
+		UpdateExpression:    aws.String("set WinnerID = :w, EndReason = :r, #st = :st"),
+		ConditionExpression: aws.String("#st = :from"),
+	})
+
+	if isConditionalCheckFailed(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func isConditionalCheckFailed(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package rooms
+
+import (
+	"sync"
+	"time"
+
+	"github.com/uu64/two-apps/two-back/lib/game"
+)
+
+// MemoryStore is the RoomStore that keeps the rooms in memory
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]Room
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: map[string]Room{}}
+}
+
+// copyRoom returns the room which shares no slice with the stored one
+func copyRoom(room Room) Room {
+	room.Problem = append([]int(nil), room.Problem...)
+	room.ReadyUsers = append([]string(nil), room.ReadyUsers...)
+	return room
+}
+
+func (s *MemoryStore) getItem(id string) (Room, error) {
+	room, ok := s.items[id]
+	if !ok {
+		return Room{}, ErrRoomNotFound
+	}
+	return room, nil
+}
+
+// Get returns the room with the id
+func (s *MemoryStore) Get(id string) (Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.getItem(id)
+	return copyRoom(room), err
+}
+
+// Users returns the connection-id of the user in the room
+func (s *MemoryStore) Users(id string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.getItem(id)
+	return []string{room.User1ID, room.User2ID}, err
+}
+
+// Status returns the status of the room
+func (s *MemoryStore) Status(id string) (RoomStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.getItem(id)
+	return room.Status, err
+}
+
+// Problem returns the problem of the room
+func (s *MemoryStore) Problem(id string) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.getItem(id)
+	return append([]int(nil), room.Problem...), err
+}
+
+// Create creates a room and returns the room-id
+func (s *MemoryStore) Create(userID string) (string, error) {
+	roomID, err := newRoomID()
+	if err != nil {
+		return roomID, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[roomID] = Room{
+		RoomID:    roomID,
+		Status:    RoomStatusWaiting,
+		User1ID:   userID,
+		User2ID:   "",
+		ExpiresAt: time.Now().Add(roomTTL).Unix(),
+	}
+	return roomID, nil
+}
+
+// Delete deletes the room with the id
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, id)
+	return nil
+}
+
+// AddUser adds the user to the room
+func (s *MemoryStore) AddUser(id string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.items[id]
+	if !ok || !room.Status.CanTransition(RoomStatusPreparing) || room.User2ID != "" {
+		return ErrRoomNotWaiting
+	}
+
+	room.User2ID = userID
+	room.Status = RoomStatusPreparing
+	s.items[id] = room
+	return nil
+}
+
+// StartGame sets a problem, its seed, its spec, its time limit and its start to the room
+func (s *MemoryStore) StartGame(id string, problem []int, seed int64, spec game.Spec, timeLimit int, startedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.items[id]
+	if !ok || !room.Status.CanTransition(RoomStatusPlaying) {
+		return ErrInvalidTransition
+	}
+
+	room.Problem = append([]int(nil), problem...)
+	room.Seed = seed
+	room.Spec = spec
+	room.TimeLimit = timeLimit
+	room.StartedAt = startedAt.UnixNano() / int64(time.Millisecond)
+	room.Status = RoomStatusPlaying
+	s.items[id] = room
+	return nil
+}
+
+// Ready marks the user in the room as ready and returns the updated room
+func (s *MemoryStore) Ready(id string, userID string) (Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.items[id]
+	if !ok || room.Status != RoomStatusPreparing ||
+		(room.User1ID != userID && room.User2ID != userID) {
+		return Room{}, ErrInvalidTransition
+	}
+
+	if !room.IsReady(userID) {
+		room.ReadyUsers = append(copyRoom(room).ReadyUsers, userID)
+	}
+	s.items[id] = room
+	return copyRoom(room), nil
+}
+
+// Finish records the result and finishes the game
+func (s *MemoryStore) Finish(id string, winnerID string, reason string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.items[id]
+	if !ok || !room.Status.CanTransition(RoomStatusFinished) {
+		return false, nil
+	}
+
+	room.WinnerID = winnerID
+	room.EndReason = reason
+	room.Status = RoomStatusFinished
+	s.items[id] = room
+	return true, nil
+}
+
+// Transition moves the room from the status to next
+func (s *MemoryStore) Transition(id string, from RoomStatus, next RoomStatus) error {
+	if !from.CanTransition(next) {
+		return ErrInvalidTransition
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.items[id]
+	if !ok || room.Status != from {
+		return ErrInvalidTransition
+	}
+
+	room.Status = next
+	s.items[id] = room
+	return nil
+}
@@ -0,0 +1,64 @@
+package rooms
+
+import (
+	"errors"
+)
+
+// RoomStatus is status of the rooms table item
+type RoomStatus string
+
+// RoomStatusWaiting is the status of the room waiting for a challenger
+const RoomStatusWaiting RoomStatus = "WAITING"
+
+// RoomStatusPreparing is the status of the room whose game is not started yet
+const RoomStatusPreparing RoomStatus = "PREPARING"
+
+// RoomStatusPlaying is the status of the room whose game is in progress
+const RoomStatusPlaying RoomStatus = "PLAYING"
+
+// RoomStatusFinished is the status of the room whose game has a winner
+const RoomStatusFinished RoomStatus = "FINISHED"
+
+// RoomStatusAbandoned is the status of the room a user left before the end
+const RoomStatusAbandoned RoomStatus = "ABANDONED"
+
+// ErrInvalidTransition is returned when the room cannot move to the status
+var ErrInvalidTransition = errors.New("room status transition is invalid")
+
+var transitions = map[RoomStatus][]RoomStatus{
+	RoomStatusWaiting:   {RoomStatusPreparing, RoomStatusAbandoned},
+	RoomStatusPreparing: {RoomStatusPlaying, RoomStatusAbandoned},
+	RoomStatusPlaying:   {RoomStatusFinished, RoomStatusAbandoned},
+	RoomStatusFinished:  {},
+	RoomStatusAbandoned: {},
+}
+
+// CanTransition returns whether the room can move from the status to next
+func (s RoomStatus) CanTransition(next RoomStatus) bool {
+	for _, v := range transitions[s] {
+		if v == next {
+			return true
+		}
+	}
+	return false
+}
+
+// previous returns the status the room moves to s from.
+// It returns false unless there is exactly one such status.
+func (s RoomStatus) previous() (RoomStatus, bool) {
+	var from []RoomStatus
+	for status := range transitions {
+		if status.CanTransition(s) {
+			from = append(from, status)
+		}
+	}
+	if len(from) != 1 {
+		return "", false
+	}
+	return from[0], true
+}
+
+// Terminal returns whether the status never changes any more
+func (s RoomStatus) Terminal() bool {
+	return len(transitions[s]) == 0
+}
@@ -2,25 +2,72 @@ package users
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
 var userTableName string = "users"
 
+// ErrUserNotFound is returned when the user does not exist
+var ErrUserNotFound = errors.New("user is not exist")
+
 // User is defintion of the users table item
 type User struct {
 	ConnectionID string
 	RoomID       string
 	Solved       bool
+	// PlayerID is the player the connection plays as, which outlives the user
+	PlayerID string
+	// Level is the level the user prefers, or 0 without preference
+	Level int
+	// DailyDate is the day of the daily challenge the user has started
+	DailyDate string
+}
+
+// UserStore is the storage of the users
+type UserStore interface {
+	// Create creates a user.
+	// level is the level the user prefers, or 0 without preference.
+	Create(connectionID string, playerID string, roomID string, level int) error
+	// RoomID returns the room-id of the room the user belongs to
+	RoomID(id string) (string, error)
+	// PlayerID returns the player the user plays as
+	PlayerID(id string) (string, error)
+	// Solved returns whether the user solved the problem
+	Solved(id string) (bool, error)
+	// SolveProblem updates "Solved" to true
+	SolveProblem(id string) error
+	// Level returns the level the user prefers
+	Level(id string) (int, error)
+	// SetLevel updates the level the user prefers
+	SetLevel(id string, level int) error
+	// DailyDate returns the day of the daily challenge the user has started,
+	// or an empty string before the user starts one
+	DailyDate(id string) (string, error)
+	// SetDailyDate updates the day of the daily challenge the user has started
+	SetDailyDate(id string, date string) error
+	// Delete deletes the user with the id
+	Delete(id string) error
+}
+
+// DynamoStore is the UserStore backed by the users table
+type DynamoStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewDynamoStore returns the UserStore using the dynamodb client
+func NewDynamoStore(svc *dynamodb.DynamoDB) *DynamoStore {
+	return &DynamoStore{svc: svc}
 }
 
-func getItem(svc *dynamodb.DynamoDB, id string) (User, error) {
+func (s *DynamoStore) getItem(id string) (User, error) {
 	user := User{}
 
-	result, err := svc.GetItem(&dynamodb.GetItemInput{
+	result, err := s.svc.GetItem(&dynamodb.GetItemInput{
 		TableName: aws.String(userTableName),
 		Key: map[string]*dynamodb.AttributeValue{
 			"ConnectionID": {
@@ -33,7 +80,7 @@ func getItem(svc *dynamodb.DynamoDB, id string) (User, error) {
 	}
 
 	if result.Item == nil {
-		return user, errors.New("user is not exist")
+		return user, ErrUserNotFound
 	}
 
 	err = dynamodbattribute.UnmarshalMap(result.Item, &user)
@@ -41,30 +88,38 @@ func getItem(svc *dynamodb.DynamoDB, id string) (User, error) {
 }
 
 // RoomID returns the room-id of the room the user belongs to
-func RoomID(svc *dynamodb.DynamoDB, id string) (string, error) {
-	user, err := getItem(svc, id)
+func (s *DynamoStore) RoomID(id string) (string, error) {
+	user, err := s.getItem(id)
 	return user.RoomID, err
 }
 
+// PlayerID returns the player the user plays as
+func (s *DynamoStore) PlayerID(id string) (string, error) {
+	user, err := s.getItem(id)
+	return user.PlayerID, err
+}
+
 // Solved returns whether the user solved the problem
-func Solved(svc *dynamodb.DynamoDB, id string) (bool, error) {
-	user, err := getItem(svc, id)
+func (s *DynamoStore) Solved(id string) (bool, error) {
+	user, err := s.getItem(id)
 	return user.Solved, err
 }
 
 // Create creates a user
-func Create(svc *dynamodb.DynamoDB, connectionID string, roomID string) error {
+func (s *DynamoStore) Create(connectionID string, playerID string, roomID string, level int) error {
 	item := User{
 		ConnectionID: connectionID,
+		PlayerID:     playerID,
 		RoomID:       roomID,
 		Solved:       false,
+		Level:        level,
 	}
 	av, err := dynamodbattribute.MarshalMap(item)
 	if err != nil {
 		return err
 	}
 
-	_, err = svc.PutItem(&dynamodb.PutItemInput{
+	_, err = s.svc.PutItem(&dynamodb.PutItemInput{
 		Item:      av,
 		TableName: aws.String(userTableName),
 	})
@@ -76,8 +131,8 @@ func Create(svc *dynamodb.DynamoDB, connectionID string, roomID string) error {
 }
 
 // Delete deletes the user with the id
-func Delete(svc *dynamodb.DynamoDB, id string) error {
-	_, err := svc.DeleteItem(&dynamodb.DeleteItemInput{
+func (s *DynamoStore) Delete(id string) error {
+	_, err := s.svc.DeleteItem(&dynamodb.DeleteItemInput{
 		TableName: aws.String(userTableName),
 		Key: map[string]*dynamodb.AttributeValue{
 			"ConnectionID": {
@@ -92,8 +147,8 @@ func Delete(svc *dynamodb.DynamoDB, id string) error {
 }
 
 // SolveProblem updates "Solved" to true
-func SolveProblem(svc *dynamodb.DynamoDB, id string) error {
-	_, err := svc.UpdateItem(&dynamodb.UpdateItemInput{
+func (s *DynamoStore) SolveProblem(id string) error {
+	_, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":s": {
 				BOOL: aws.Bool(true),
@@ -115,3 +170,75 @@ func SolveProblem(svc *dynamodb.DynamoDB, id string) error {
 
 	return nil
 }
+
+// Level returns the level the user prefers
+func (s *DynamoStore) Level(id string) (int, error) {
+	user, err := s.getItem(id)
+	return user.Level, err
+}
+
+// SetLevel updates the level the user prefers
+func (s *DynamoStore) SetLevel(id string, level int) error {
+	_, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]*string{
+			"#l": aws.String("Level"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":l": {
+				N: aws.String(strconv.Itoa(level)),
+			},
+		},
+		TableName: aws.String(userTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"ConnectionID": {
+				S: aws.String(id),
+			},
+		},
+		ReturnValues:        aws.String("UPDATED_NEW"),
+		UpdateExpression:    aws.String("set #l = :l"),
+		ConditionExpression: aws.String("attribute_exists(ConnectionID)"),
+	})
+
+	if isConditionalCheckFailed(err) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// DailyDate returns the day of the daily challenge the user has started
+func (s *DynamoStore) DailyDate(id string) (string, error) {
+	user, err := s.getItem(id)
+	return user.DailyDate, err
+}
+
+// SetDailyDate updates the day of the daily challenge the user has started
+func (s *DynamoStore) SetDailyDate(id string, date string) error {
+	_, err := s.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":d": {
+				S: aws.String(date),
+			},
+		},
+		TableName: aws.String(userTableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"ConnectionID": {
+				S: aws.String(id),
+			},
+		},
+		ReturnValues:        aws.String("UPDATED_NEW"),
+		UpdateExpression:    aws.String("set DailyDate = :d"),
+		ConditionExpression: aws.String("attribute_exists(ConnectionID)"),
+	})
+
+	if isConditionalCheckFailed(err) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func isConditionalCheckFailed(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package users
+
+import (
+	"sync"
+)
+
+// MemoryStore is the UserStore that keeps the users in memory
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]User
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: map[string]User{}}
+}
+
+func (s *MemoryStore) getItem(id string) (User, error) {
+	user, ok := s.items[id]
+	if !ok {
+		return User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// RoomID returns the room-id of the room the user belongs to
+func (s *MemoryStore) RoomID(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getItem(id)
+	return user.RoomID, err
+}
+
+// PlayerID returns the player the user plays as
+func (s *MemoryStore) PlayerID(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getItem(id)
+	return user.PlayerID, err
+}
+
+// Solved returns whether the user solved the problem
+func (s *MemoryStore) Solved(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getItem(id)
+	return user.Solved, err
+}
+
+// Create creates a user
+func (s *MemoryStore) Create(connectionID string, playerID string, roomID string, level int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[connectionID] = User{
+		ConnectionID: connectionID,
+		PlayerID:     playerID,
+		RoomID:       roomID,
+		Solved:       false,
+		Level:        level,
+	}
+	return nil
+}
+
+// Delete deletes the user with the id
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, id)
+	return nil
+}
+
+// SolveProblem updates "Solved" to true
+func (s *MemoryStore) SolveProblem(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getItem(id)
+	if err != nil {
+		return err
+	}
+
+	user.Solved = true
+	s.items[id] = user
+	return nil
+}
+
+// Level returns the level the user prefers
+func (s *MemoryStore) Level(id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getItem(id)
+	return user.Level, err
+}
+
+// SetLevel updates the level the user prefers
+func (s *MemoryStore) SetLevel(id string, level int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getItem(id)
+	if err != nil {
+		return err
+	}
+
+	user.Level = level
+	s.items[id] = user
+	return nil
+}
+
+// DailyDate returns the day of the daily challenge the user has started
+func (s *MemoryStore) DailyDate(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getItem(id)
+	return user.DailyDate, err
+}
+
+// SetDailyDate updates the day of the daily challenge the user has started
+func (s *MemoryStore) SetDailyDate(id string, date string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.getItem(id)
+	if err != nil {
+		return err
+	}
+
+	user.DailyDate = date
+	s.items[id] = user
+	return nil
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sqs"
+	myqueue "github.com/uu64/two-apps/two-back/lib/interface/sqs"
+)
+
+// JobReadyCheck cancels the match unless both users are ready
+const JobReadyCheck string = "READY_CHECK"
+
+// JobWaitingTimeout closes the room unless a challenger has joined
+const JobWaitingTimeout string = "WAITING_TIMEOUT"
+
+// JobCrossMatch matches the room with a room of another bracket
+// once it has waited long enough to accept any level
+const JobCrossMatch string = "CROSS_MATCH"
+
+// JobTimeUp ends the game as a draw unless somebody has solved it
+const JobTimeUp string = "TIME_UP"
+
+// maxSQSDelay is the longest delay of a sqs message
+var maxSQSDelay time.Duration = 15 * time.Minute
+
+// Job is the work for the room which runs after a delay
+type Job struct {
+	Kind   string `json:"kind"`
+	RoomID string `json:"roomId"`
+	// Endpoint is the websocket api the users are connected to
+	Endpoint string `json:"endpoint"`
+}
+
+// Scheduler runs the job after the delay
+type Scheduler interface {
+	Schedule(job Job, delay time.Duration) error
+}
+
+// SQSScheduler is the Scheduler sending the job to the queue as a delayed message.
+// The delay is up to 15 minutes.
+type SQSScheduler struct {
+	svc       *sqs.SQS
+	queueName string
+}
+
+// NewSQSScheduler returns the Scheduler using the queue
+func NewSQSScheduler(svc *sqs.SQS, queueName string) *SQSScheduler {
+	return &SQSScheduler{svc: svc, queueName: queueName}
+}
+
+// Schedule sends the job to the queue as json
+func (s *SQSScheduler) Schedule(job Job, delay time.Duration) error {
+	if delay > maxSQSDelay {
+		delay = maxSQSDelay
+	}
+
+	data, err := json.Marshal(&job)
+	if err != nil {
+		return err
+	}
+	return myqueue.SendDelayedMessage(s.svc, s.queueName, string(data), int64(delay/time.Second))
+}
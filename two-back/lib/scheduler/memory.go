@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// TimerScheduler is the Scheduler running the jobs with the timers of this process
+type TimerScheduler struct {
+	run func(job Job)
+}
+
+// NewTimerScheduler returns the Scheduler calling run for every job
+func NewTimerScheduler(run func(job Job)) *TimerScheduler {
+	return &TimerScheduler{run: run}
+}
+
+// Schedule runs the job in its own goroutine after the delay
+func (s *TimerScheduler) Schedule(job Job, delay time.Duration) error {
+	time.AfterFunc(delay, func() {
+		s.run(job)
+	})
+	return nil
+}
+
+type scheduledJob struct {
+	job Job
+	at  time.Duration
+}
+
+// ManualScheduler is the Scheduler with a virtual clock for tests.
+// The jobs run only when the clock is advanced.
+type ManualScheduler struct {
+	mu   sync.Mutex
+	now  time.Duration
+	jobs []scheduledJob
+}
+
+// NewManualScheduler returns the Scheduler whose clock starts at zero
+func NewManualScheduler() *ManualScheduler {
+	return &ManualScheduler{}
+}
+
+// Schedule keeps the job until the clock passes the delay
+func (s *ManualScheduler) Schedule(job Job, delay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, scheduledJob{job: job, at: s.now + delay})
+	return nil
+}
+
+// Advance moves the clock and returns the jobs which are due in order
+func (s *ManualScheduler) Advance(d time.Duration) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now += d
+	sort.SliceStable(s.jobs, func(i, j int) bool {
+		return s.jobs[i].at < s.jobs[j].at
+	})
+
+	var due []Job
+	for len(s.jobs) > 0 && s.jobs[0].at <= s.now {
+		due = append(due, s.jobs[0].job)
+		s.jobs = s.jobs[1:]
+	}
+	return due
+}
+
+// Now returns the time of the clock, which starts at the unix epoch
+func (s *ManualScheduler) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return time.Unix(0, 0).Add(s.now)
+}
+
+// Pending returns the number of the jobs not run yet
+func (s *ManualScheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.jobs)
+}
@@ -0,0 +1,437 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/daily"
+	"github.com/uu64/two-apps/two-back/lib/handler/join"
+	"github.com/uu64/two-apps/two-back/lib/handler/leave"
+	"github.com/uu64/two-apps/two-back/lib/handler/problem"
+	"github.com/uu64/two-apps/two-back/lib/handler/ready"
+	"github.com/uu64/two-apps/two-back/lib/handler/solve"
+	"github.com/uu64/two-apps/two-back/lib/handler/start"
+	"github.com/uu64/two-apps/two-back/lib/handler/timeout"
+	"github.com/uu64/two-apps/two-back/lib/interface/ws"
+	"github.com/uu64/two-apps/two-back/lib/matchmaker"
+	"github.com/uu64/two-apps/two-back/lib/rating"
+	"github.com/uu64/two-apps/two-back/lib/repository/players"
+	"github.com/uu64/two-apps/two-back/lib/repository/results"
+	"github.com/uu64/two-apps/two-back/lib/repository/rooms"
+	"github.com/uu64/two-apps/two-back/lib/repository/users"
+	"github.com/uu64/two-apps/two-back/lib/scheduler"
+)
+
+type request = events.APIGatewayWebsocketProxyRequest
+type response = events.APIGatewayProxyResponse
+
+type route func(ctx context.Context, request request) (response, error)
+
+// DomainName is the domain name of the simulated websocket api
+var DomainName string = "example.execute-api.ap-northeast-1.amazonaws.com"
+
+// Stage is the stage of the simulated websocket api
+var Stage string = "test"
+
+// Simulator drives the handlers like api gateway does
+// against the in-memory stores and the recording notifier
+type Simulator struct {
+	Rooms      *rooms.MemoryStore
+	Users      *users.MemoryStore
+	Results    *results.MemoryStore
+	Players    *players.MemoryStore
+	Matchmaker *matchmaker.MemoryMatchmaker
+	Notifier   *ws.Recorder
+	// Scheduler runs the scheduled jobs only when the clock is advanced
+	Scheduler *scheduler.ManualScheduler
+
+	// HoldAnnouncements keeps the matched rooms from starting
+	// until DeliverAnnouncements is called
+	HoldAnnouncements bool
+	// HoldJobs keeps the due jobs from running until RunJobs is called,
+	// like the queue consumer running late
+	HoldJobs bool
+
+	connect       route
+	leave         route
+	routes        map[string]route
+	open          map[string]bool
+	start         func(ctx context.Context, a matchmaker.Announcement) error
+	run           func(ctx context.Context, job scheduler.Job) error
+	announcements []matchmaker.Announcement
+	jobs          []scheduler.Job
+	readyHandler  *ready.Handler
+}
+
+// New returns the Simulator with empty stores
+func New() *Simulator {
+	s := &Simulator{
+		Rooms:      rooms.NewMemoryStore(),
+		Users:      users.NewMemoryStore(),
+		Results:    results.NewMemoryStore(),
+		Players:    players.NewMemoryStore(),
+		Matchmaker: matchmaker.NewMemoryMatchmaker(),
+		Notifier:   ws.NewRecorder(),
+		Scheduler:  scheduler.NewManualScheduler(),
+		open:       map[string]bool{},
+	}
+	s.Matchmaker.SetClock(s.Scheduler.Now)
+	rater := &rating.Rater{
+		Players: s.Players,
+		Users:   s.Users,
+	}
+
+	joinHandler := &join.Handler{
+		Rooms:      s.Rooms,
+		Users:      s.Users,
+		Matchmaker: s.Matchmaker,
+		Announcer: matchmaker.AnnouncerFunc(func(a matchmaker.Announcement) error {
+			s.announcements = append(s.announcements, a)
+			return nil
+		}),
+		Scheduler: s.Scheduler,
+	}
+	startHandler := &start.Handler{
+		Rooms:     s.Rooms,
+		Notifier:  s.Notifier.Factory(),
+		Scheduler: s.Scheduler,
+	}
+	timeoutHandler := &timeout.Handler{
+		Rooms:    s.Rooms,
+		Notifier: s.Notifier.Factory(),
+		Rater:    rater,
+		Lobby:    joinHandler,
+	}
+	leaveHandler := &leave.Handler{
+		Rooms:      s.Rooms,
+		Users:      s.Users,
+		Matchmaker: s.Matchmaker,
+		Notifier:   s.Notifier.Factory(),
+		Rater:      rater,
+		Clock:      s.Scheduler.Now,
+	}
+	readyHandler := &ready.Handler{
+		Rooms:     s.Rooms,
+		Users:     s.Users,
+		Notifier:  s.Notifier.Factory(),
+		Scheduler: s.Scheduler,
+		Clock:     s.Scheduler.Now,
+	}
+	problemHandler := &problem.Handler{
+		Rooms:    s.Rooms,
+		Users:    s.Users,
+		Notifier: s.Notifier.Factory(),
+	}
+	solveHandler := &solve.Handler{
+		Rooms:    s.Rooms,
+		Users:    s.Users,
+		Notifier: s.Notifier.Factory(),
+		Rater:    rater,
+		Clock:    s.Scheduler.Now,
+	}
+	dailyHandler := &daily.Handler{
+		Results:  s.Results,
+		Users:    s.Users,
+		Notifier: s.Notifier.Factory(),
+		Clock:    s.Scheduler.Now,
+	}
+
+	s.readyHandler = readyHandler
+	s.start = startHandler.Start
+	s.run = timeoutHandler.Run
+	s.connect = joinHandler.Handle
+	s.leave = leaveHandler.Handle
+	s.routes = map[string]route{
+		"daily":   dailyHandler.Handle,
+		"problem": problemHandler.Handle,
+		"ready":   readyHandler.Handle,
+		"solve":   solveHandler.Handle,
+	}
+	return s
+}
+
+// SetFilter makes the ready route create the problems the filter accepts
+func (s *Simulator) SetFilter(filter game.Filter) {
+	s.readyHandler.Filter = &filter
+}
+
+// NewRequest returns the request api gateway sends for the route
+func NewRequest(connectionID string, routeKey string, body string) request {
+	eventType := "MESSAGE"
+	switch routeKey {
+	case "$connect":
+		eventType = "CONNECT"
+	case "$disconnect":
+		eventType = "DISCONNECT"
+	}
+
+	return request{
+		Body: body,
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			ConnectionID: connectionID,
+			RouteKey:     routeKey,
+			EventType:    eventType,
+			DomainName:   DomainName,
+			Stage:        Stage,
+		},
+	}
+}
+
+// ConnectRequest returns the request of the $connect route.
+// level is sent as the query string unless it is 0.
+func ConnectRequest(connectionID string, level int) request {
+	request := NewRequest(connectionID, "$connect", "")
+	if level != 0 {
+		request.QueryStringParameters = map[string]string{
+			"level": strconv.Itoa(level),
+		}
+	}
+	return request
+}
+
+// PlayerConnectRequest returns the request of the $connect route
+// playing as the player
+func PlayerConnectRequest(connectionID string, playerID string) request {
+	request := NewRequest(connectionID, "$connect", "")
+	request.QueryStringParameters = map[string]string{
+		"player": playerID,
+	}
+	return request
+}
+
+// DailyConnectRequest returns the request of the $connect route
+// for the daily challenge, playing as the player unless it is empty
+func DailyConnectRequest(connectionID string, playerID string) request {
+	request := NewRequest(connectionID, "$connect", "")
+	request.QueryStringParameters = map[string]string{
+		"mode": join.ModeDaily,
+	}
+	if playerID != "" {
+		request.QueryStringParameters["player"] = playerID
+	}
+	return request
+}
+
+// DisconnectRequest returns the request of the $disconnect route
+func DisconnectRequest(connectionID string) request {
+	return NewRequest(connectionID, "$disconnect", "")
+}
+
+// ProblemRequest returns the request of the problem route
+func ProblemRequest(connectionID string, level int) request {
+	body, _ := json.Marshal(map[string]interface{}{
+		"action": "problem",
+		"level":  level,
+	})
+	return NewRequest(connectionID, "problem", string(body))
+}
+
+// ReadyRequest returns the request of the ready route.
+// level is the level the user prefers, or 0 without preference.
+func ReadyRequest(connectionID string, level int) request {
+	body, _ := json.Marshal(map[string]interface{}{
+		"action": "ready",
+		"level":  level,
+	})
+	return NewRequest(connectionID, "ready", string(body))
+}
+
+// SolveRequest returns the request of the solve route
+func SolveRequest(connectionID string, answer []string) request {
+	body, _ := json.Marshal(map[string]interface{}{
+		"action": "solve",
+		"answer": answer,
+	})
+	return NewRequest(connectionID, "solve", string(body))
+}
+
+// DailyRequest returns the request of the daily route.
+// The problem of the day is asked for when answer is nil.
+func DailyRequest(connectionID string, answer []string) request {
+	body := map[string]interface{}{
+		"action": "daily",
+	}
+	if answer != nil {
+		body["answer"] = answer
+	}
+	data, _ := json.Marshal(body)
+	return NewRequest(connectionID, "daily", string(data))
+}
+
+// Connect opens the connection and runs the $connect route
+func (s *Simulator) Connect(connectionID string) (response, error) {
+	return s.ConnectWithLevel(connectionID, 0)
+}
+
+// ConnectWithLevel opens the connection asking for the level
+// and runs the $connect route
+func (s *Simulator) ConnectWithLevel(connectionID string, level int) (response, error) {
+	return s.connectWith(ConnectRequest(connectionID, level))
+}
+
+// ConnectAs opens the connection playing as the player
+// and runs the $connect route
+func (s *Simulator) ConnectAs(connectionID string, playerID string) (response, error) {
+	return s.connectWith(PlayerConnectRequest(connectionID, playerID))
+}
+
+// connectWith opens the connection of the request and runs the $connect route
+func (s *Simulator) connectWith(request request) (response, error) {
+	connectionID := request.RequestContext.ConnectionID
+	s.open[connectionID] = true
+	res, err := s.connect(context.Background(), request)
+	if err != nil || res.StatusCode != 200 {
+		delete(s.open, connectionID)
+		return res, err
+	}
+
+	if !s.HoldAnnouncements {
+		err = s.DeliverAnnouncements()
+	}
+	return res, err
+}
+
+// ConnectDaily opens the connection for the daily challenge
+// and runs the $connect route
+func (s *Simulator) ConnectDaily(connectionID string) (response, error) {
+	return s.connectWith(DailyConnectRequest(connectionID, ""))
+}
+
+// ConnectDailyAs opens the connection for the daily challenge
+// playing as the player and runs the $connect route
+func (s *Simulator) ConnectDailyAs(connectionID string, playerID string) (response, error) {
+	return s.connectWith(DailyConnectRequest(connectionID, playerID))
+}
+
+// DeliverAnnouncements tells the users of the matched rooms
+// like the queue consumer does
+func (s *Simulator) DeliverAnnouncements() error {
+	announcements := s.announcements
+	s.announcements = nil
+
+	for _, a := range announcements {
+		err := s.start(context.Background(), a)
+		if err != nil {
+			return err
+		}
+	}
+	s.closeDisconnected()
+	return nil
+}
+
+// Advance moves the clock of the scheduler and runs the jobs which are due
+func (s *Simulator) Advance(d time.Duration) error {
+	s.jobs = append(s.jobs, s.Scheduler.Advance(d)...)
+	if s.HoldJobs {
+		return nil
+	}
+	return s.RunJobs()
+}
+
+// RunJobs runs the due jobs held so far in order
+func (s *Simulator) RunJobs() error {
+	for len(s.jobs) > 0 {
+		job := s.jobs[0]
+		s.jobs = s.jobs[1:]
+		err := s.run(context.Background(), job)
+		if err != nil {
+			return err
+		}
+		if !s.HoldAnnouncements {
+			err = s.DeliverAnnouncements()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	s.closeDisconnected()
+	return nil
+}
+
+// Disconnect closes the connection and runs the $disconnect route.
+// The connections closed by the handler are disconnected in turn.
+func (s *Simulator) Disconnect(connectionID string) (response, error) {
+	delete(s.open, connectionID)
+	res, err := s.leave(context.Background(), DisconnectRequest(connectionID))
+	s.closeDisconnected()
+	return res, err
+}
+
+// Problem runs the problem route
+func (s *Simulator) Problem(connectionID string, level int) (response, error) {
+	return s.Do(ProblemRequest(connectionID, level))
+}
+
+// Ready runs the ready route
+func (s *Simulator) Ready(connectionID string, level int) (response, error) {
+	return s.Do(ReadyRequest(connectionID, level))
+}
+
+// Solve runs the solve route
+func (s *Simulator) Solve(connectionID string, answer []string) (response, error) {
+	return s.Do(SolveRequest(connectionID, answer))
+}
+
+// Daily runs the daily route, asking for the problem of the day when answer is nil
+func (s *Simulator) Daily(connectionID string, answer []string) (response, error) {
+	return s.Do(DailyRequest(connectionID, answer))
+}
+
+// Do runs the route of the request
+func (s *Simulator) Do(request request) (response, error) {
+	connectionID := request.RequestContext.ConnectionID
+	if !s.open[connectionID] {
+		return response{StatusCode: 410}, fmt.Errorf("connection %s is gone", connectionID)
+	}
+
+	route, ok := s.routes[request.RequestContext.RouteKey]
+	if !ok {
+		return response{StatusCode: 404}, fmt.Errorf("route %s is not found", request.RequestContext.RouteKey)
+	}
+
+	res, err := route(context.Background(), request)
+	s.closeDisconnected()
+	return res, err
+}
+
+// closeDisconnected runs the $disconnect route for the connections
+// the handlers have closed, as api gateway does after DeleteConnection
+func (s *Simulator) closeDisconnected() {
+	for id := range s.open {
+		if s.Notifier.Disconnected(id) {
+			s.Disconnect(id)
+		}
+	}
+}
+
+// Open returns whether the connection is still open
+func (s *Simulator) Open(connectionID string) bool {
+	return s.open[connectionID]
+}
+
+// Messages returns the "message" of every frame sent to the connection
+func (s *Simulator) Messages(connectionID string) []string {
+	var messages []string
+	for _, frame := range s.Notifier.Frames(connectionID) {
+		var v struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(frame, &v)
+		messages = append(messages, v.Message)
+	}
+	return messages
+}
+
+// LastFrame decodes the last frame sent to the connection into v
+func (s *Simulator) LastFrame(connectionID string, v interface{}) error {
+	frames := s.Notifier.Frames(connectionID)
+	if len(frames) == 0 {
+		return fmt.Errorf("no frame is sent to %s", connectionID)
+	}
+	return json.Unmarshal(frames[len(frames)-1], v)
+}
@@ -0,0 +1,1049 @@
+package simulator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uu64/two-apps/two-back/lib/game"
+	"github.com/uu64/two-apps/two-back/lib/handler/join"
+	"github.com/uu64/two-apps/two-back/lib/handler/ready"
+	"github.com/uu64/two-apps/two-back/lib/handler/start"
+	"github.com/uu64/two-apps/two-back/lib/repository/players"
+)
+
+// answerFor finds the operators making 2 by trying every assignment
+func answerFor(t *testing.T, problem []int) []string {
+	t.Helper()
+	return answerForSpec(t, game.DefaultSpec, problem)
+}
+
+// answerForSpec finds the operators of the spec making its target
+// by trying every assignment
+func answerForSpec(t *testing.T, spec game.Spec, problem []int) []string {
+	t.Helper()
+
+	n := len(problem) - 1
+	answer := make([]string, n)
+	var try func(i int) bool
+	try = func(i int) bool {
+		if i == n {
+			return game.CheckAnswer(spec, problem, answer)
+		}
+		for _, op := range spec.Operators {
+			answer[i] = op
+			if try(i + 1) {
+				return true
+			}
+		}
+		return false
+	}
+	if try(0) {
+		return answer
+	}
+
+	t.Fatalf("problem %v has no answer", problem)
+	return nil
+}
+
+func assertMessages(t *testing.T, s *Simulator, connectionID string, want ...string) {
+	t.Helper()
+
+	got := s.Messages(connectionID)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("messages to %s = %v, want %v", connectionID, got, want)
+	}
+}
+
+// startGame connects two players and gets both ready
+func startGame(t *testing.T, s *Simulator, player1 string, player2 string) []int {
+	t.Helper()
+
+	if _, err := s.Connect(player1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Connect(player2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Ready(player1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Ready(player2, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var frame struct {
+		Message string    `json:"message"`
+		Problem []int     `json:"problem"`
+		Spec    game.Spec `json:"spec"`
+	}
+	if err := s.LastFrame(player2, &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Message != "START_GAME" || len(frame.Problem) != 5 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	if frame.Spec.Target != 2 || frame.Spec.Terms != 5 {
+		t.Fatalf("unexpected spec %+v", frame.Spec)
+	}
+	for _, term := range frame.Problem {
+		if term <= 0 {
+			t.Fatalf("problem %v has a negative or zero term", frame.Problem)
+		}
+	}
+	features := game.Analyze(frame.Spec, frame.Problem)
+	if features.MinusSigns == 0 {
+		t.Fatalf("problem %v is solved with plus only", frame.Problem)
+	}
+	score := features.Score(len(frame.Spec.Operators))
+	if !frame.Spec.Difficulty.Contains(score) {
+		t.Fatalf("score %v of problem %v is out of %+v", score, frame.Problem, frame.Spec.Difficulty)
+	}
+	return frame.Problem
+}
+
+func TestMatchAndWin(t *testing.T) {
+	s := New()
+	problem := startGame(t, s, "alice", "bob")
+
+	if _, err := s.Solve("alice", answerFor(t, problem)); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME", "YOU_WIN")
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME", "YOU_LOSE")
+}
+
+func TestWrongAnswer(t *testing.T) {
+	s := New()
+	problem := startGame(t, s, "alice", "bob")
+
+	if _, err := s.Solve("bob", []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Solve("bob", answerFor(t, problem)); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME", "YOU_LOSE")
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME", "WRONG_ANSWER", "YOU_WIN")
+}
+
+func TestOnlyOneWinner(t *testing.T) {
+	s := New()
+	problem := startGame(t, s, "alice", "bob")
+	answer := answerFor(t, problem)
+
+	s.Solve("alice", answer)
+	res, err := s.Solve("bob", answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 400 {
+		t.Errorf("status code = %d, want 400", res.StatusCode)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME", "YOU_WIN")
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME", "YOU_LOSE", "GAME_OVER")
+}
+
+func TestMatchedWaitsForReady(t *testing.T) {
+	s := New()
+	s.HoldAnnouncements = true
+	s.Connect("alice")
+	s.Connect("bob")
+	assertMessages(t, s, "alice")
+
+	if err := s.DeliverAnnouncements(); err != nil {
+		t.Fatal(err)
+	}
+	s.Ready("alice", 0)
+
+	assertMessages(t, s, "alice", "MATCHED")
+	assertMessages(t, s, "bob", "MATCHED")
+
+	s.Ready("bob", 0)
+
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME")
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME")
+}
+
+func TestProblemFromSeed(t *testing.T) {
+	s := New()
+	problem := startGame(t, s, "alice", "bob")
+	roomID, _ := s.Users.RoomID("alice")
+
+	room, err := s.Rooms.Get(roomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := game.CreateProblem(room.Spec, room.Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, problem) {
+		t.Errorf("problem from seed %d = %v, want %v", room.Seed, again, problem)
+	}
+}
+
+func TestLevelNegotiation(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+	s.Connect("bob")
+	s.Ready("alice", 7)
+	s.Ready("bob", 3)
+
+	var frame struct {
+		Message string `json:"message"`
+		Problem []int  `json:"problem"`
+		Level   int    `json:"level"`
+	}
+	for _, player := range []string{"alice", "bob"} {
+		if err := s.LastFrame(player, &frame); err != nil {
+			t.Fatal(err)
+		}
+		if frame.Message != "START_GAME" || frame.Level != 3 || len(frame.Problem) != 3 {
+			t.Errorf("frame to %s = %+v, want START_GAME at level 3", player, frame)
+		}
+	}
+}
+
+func TestReadyWithInvalidLevel(t *testing.T) {
+	// level 1 has no operator to choose, so nothing would win it
+	for _, level := range []int{1, 11} {
+		s := New()
+		s.Connect("alice")
+		s.Connect("bob")
+
+		res, err := s.Ready("alice", level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != 400 {
+			t.Errorf("level %d: status code = %d, want 400", level, res.StatusCode)
+		}
+		assertMessages(t, s, "alice", "MATCHED", "INVALID_PARAMETER")
+	}
+}
+
+func TestNoProblemForLevel(t *testing.T) {
+	s := New()
+	// no problem of level 9 has as few answers
+	s.SetFilter(game.Filter{MaxSolutions: 3})
+	s.Connect("alice")
+	s.Connect("bob")
+	roomID, _ := s.Users.RoomID("alice")
+	s.Ready("alice", 9)
+
+	res, err := s.Ready("bob", 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 200 {
+		t.Errorf("status code = %d, want 200", res.StatusCode)
+	}
+
+	for _, player := range []string{"alice", "bob"} {
+		assertMessages(t, s, player, "MATCHED", "NO_PROBLEM")
+		if s.Open(player) {
+			t.Errorf("%s is still connected", player)
+		}
+		if _, err := s.Users.RoomID(player); err == nil {
+			t.Errorf("%s is not deleted", player)
+		}
+	}
+	status, _ := s.Rooms.Status(roomID)
+	if status != "ABANDONED" {
+		t.Errorf("status = %s, want ABANDONED", status)
+	}
+
+	// the jobs of the room find it abandoned
+	if err := s.Advance(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	assertMessages(t, s, "alice", "MATCHED", "NO_PROBLEM")
+}
+
+func TestReadyTimeout(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+	s.Connect("bob")
+	oldRoom, _ := s.Users.RoomID("alice")
+	s.Ready("alice", 0)
+
+	if err := s.Advance(start.DefaultReadyTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "MATCH_CANCELLED")
+	assertMessages(t, s, "bob", "MATCHED", "READY_TIMEOUT")
+	if s.Open("bob") {
+		t.Error("bob is still connected")
+	}
+	status, _ := s.Rooms.Status(oldRoom)
+	if status != "ABANDONED" {
+		t.Errorf("status = %s, want ABANDONED", status)
+	}
+
+	// alice waits in a new room for the next challenger
+	aliceRoom, _ := s.Users.RoomID("alice")
+	if aliceRoom == oldRoom {
+		t.Error("alice is still in the cancelled room")
+	}
+	s.Connect("carol")
+	carolRoom, _ := s.Users.RoomID("carol")
+	if carolRoom != aliceRoom {
+		t.Errorf("carol joined %s, want the room of alice %s", carolRoom, aliceRoom)
+	}
+	assertMessages(t, s, "alice", "MATCHED", "MATCH_CANCELLED", "MATCHED")
+}
+
+func TestReadyInTime(t *testing.T) {
+	s := New()
+	startGame(t, s, "alice", "bob")
+	roomID, _ := s.Users.RoomID("alice")
+
+	if err := s.Advance(start.DefaultReadyTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME")
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME")
+	status, _ := s.Rooms.Status(roomID)
+	if status != "PLAYING" {
+		t.Errorf("status = %s, want PLAYING", status)
+	}
+}
+
+func TestNobodyReady(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+	s.Connect("bob")
+
+	if err := s.Advance(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, player := range []string{"alice", "bob"} {
+		assertMessages(t, s, player, "MATCHED", "READY_TIMEOUT")
+		if s.Open(player) {
+			t.Errorf("%s is still connected", player)
+		}
+		if _, err := s.Users.RoomID(player); err == nil {
+			t.Errorf("%s is not deleted", player)
+		}
+	}
+}
+
+func TestProblemWhilePreparing(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+	s.Connect("bob")
+
+	if _, err := s.Problem("alice", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "PLEASE_READY")
+}
+
+func TestProblemWhileWaiting(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+
+	if _, err := s.Problem("alice", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "PLEASE_WAIT")
+}
+
+func TestProblemWhilePlaying(t *testing.T) {
+	s := New()
+	startGame(t, s, "alice", "bob")
+
+	res, err := s.Problem("bob", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 400 {
+		t.Errorf("status code = %d, want 400", res.StatusCode)
+	}
+
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME", "GAME_ALREADY_STARTED")
+}
+
+func TestDisconnectClosesRoom(t *testing.T) {
+	s := New()
+	startGame(t, s, "alice", "bob")
+	roomID, _ := s.Users.RoomID("alice")
+
+	if _, err := s.Disconnect("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Open("bob") {
+		t.Error("bob is still connected")
+	}
+	if _, err := s.Users.RoomID("bob"); err == nil {
+		t.Error("bob is not deleted")
+	}
+
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME", "OPPONENT_LEFT", "YOU_WIN")
+	room, _ := s.Rooms.Get(roomID)
+	if room.Status != "FINISHED" || room.WinnerID != "bob" || room.EndReason != "FORFEIT" {
+		t.Errorf("room = %+v, want won by bob by forfeit", room)
+	}
+}
+
+func TestDisconnectBeforeStart(t *testing.T) {
+	s := New()
+	s.HoldAnnouncements = true
+	s.Connect("alice")
+	s.Connect("bob")
+	roomID, _ := s.Users.RoomID("alice")
+
+	if _, err := s.Disconnect("bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "OPPONENT_LEFT")
+	status, _ := s.Rooms.Status(roomID)
+	if status != "ABANDONED" {
+		t.Errorf("status = %s, want ABANDONED", status)
+	}
+
+	// the announcement of the abandoned room is ignored
+	if err := s.DeliverAnnouncements(); err != nil {
+		t.Fatal(err)
+	}
+	assertMessages(t, s, "alice", "OPPONENT_LEFT")
+}
+
+func TestDisconnectWhileWaiting(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+	roomID, _ := s.Users.RoomID("alice")
+
+	if _, err := s.Disconnect("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Matchmaker.Len() != 0 {
+		t.Error("the room of alice is still in the queue")
+	}
+	status, _ := s.Rooms.Status(roomID)
+	if status != "ABANDONED" {
+		t.Errorf("status = %s, want ABANDONED", status)
+	}
+
+	s.Connect("bob")
+	bobRoom, _ := s.Users.RoomID("bob")
+	if bobRoom == roomID {
+		t.Error("bob joined the abandoned room")
+	}
+}
+
+func TestThirdPlayerGetsNewRoom(t *testing.T) {
+	s := New()
+	startGame(t, s, "alice", "bob")
+	s.Connect("carol")
+
+	aliceRoom, _ := s.Users.RoomID("alice")
+	carolRoom, _ := s.Users.RoomID("carol")
+	if aliceRoom == carolRoom {
+		t.Error("carol joined the room of alice")
+	}
+
+	status, _ := s.Rooms.Status(carolRoom)
+	if status != "WAITING" {
+		t.Errorf("status = %s, want WAITING", status)
+	}
+}
+
+func TestSkipDeadTickets(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+	aliceRoom, _ := s.Users.RoomID("alice")
+
+	// tickets left behind by a queue which cannot withdraw them
+	s.Rooms.Transition(aliceRoom, "WAITING", "ABANDONED")
+	s.Matchmaker.Enqueue(aliceRoom, 0)
+	s.Matchmaker.Enqueue("deleted-room", 0)
+	s.Connect("bob")
+	bobRoom, _ := s.Users.RoomID("bob")
+
+	s.Connect("carol")
+	carolRoom, _ := s.Users.RoomID("carol")
+	if carolRoom != bobRoom {
+		t.Errorf("carol joined %s, want the room of bob %s", carolRoom, bobRoom)
+	}
+	if s.Matchmaker.Len() != 0 {
+		t.Errorf("%d tickets are left in the queue", s.Matchmaker.Len())
+	}
+}
+
+func TestMatchWithinBracket(t *testing.T) {
+	s := New()
+	s.ConnectWithLevel("alice", 2)
+	s.ConnectWithLevel("bob", 9)
+	s.ConnectWithLevel("carol", 3)
+
+	aliceRoom, _ := s.Users.RoomID("alice")
+	bobRoom, _ := s.Users.RoomID("bob")
+	carolRoom, _ := s.Users.RoomID("carol")
+	if aliceRoom == bobRoom {
+		t.Error("bob joined the room of alice in another bracket")
+	}
+	if carolRoom != aliceRoom {
+		t.Errorf("carol joined %s, want the room of alice %s", carolRoom, aliceRoom)
+	}
+	assertMessages(t, s, "bob")
+}
+
+func TestMatchAcrossBracketsAfterWaiting(t *testing.T) {
+	s := New()
+	s.ConnectWithLevel("alice", 2)
+	s.Advance(s.Matchmaker.Brackets.CrossAfter)
+	s.ConnectWithLevel("bob", 9)
+
+	aliceRoom, _ := s.Users.RoomID("alice")
+	bobRoom, _ := s.Users.RoomID("bob")
+	if aliceRoom != bobRoom {
+		t.Error("bob did not join the room of alice waiting long")
+	}
+}
+
+func TestMatchWaitingRoomsAcrossBrackets(t *testing.T) {
+	s := New()
+	s.ConnectWithLevel("alice", 2)
+	s.ConnectWithLevel("bob", 9)
+
+	if err := s.Advance(s.Matchmaker.Brackets.CrossAfter); err != nil {
+		t.Fatal(err)
+	}
+
+	aliceRoom, _ := s.Users.RoomID("alice")
+	bobRoom, _ := s.Users.RoomID("bob")
+	if aliceRoom != bobRoom {
+		t.Fatal("alice and bob waiting in the different brackets were not matched")
+	}
+	assertMessages(t, s, "alice", "MATCHED")
+	assertMessages(t, s, "bob", "MATCHED")
+
+	if err := s.Advance(join.DefaultWaitingTimeout); err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []string{"alice", "bob"} {
+		for _, message := range s.Messages(user) {
+			if message == "NO_OPPONENT" {
+				t.Errorf("%s got NO_OPPONENT after the match", user)
+			}
+		}
+	}
+}
+
+func TestPreferSameBracket(t *testing.T) {
+	s := New()
+	s.HoldJobs = true
+	s.ConnectWithLevel("alice", 2)
+	s.Advance(20 * time.Second)
+	s.ConnectWithLevel("bob", 9)
+	s.Advance(s.Matchmaker.Brackets.CrossAfter - 20*time.Second)
+	s.ConnectWithLevel("carol", 9)
+
+	bobRoom, _ := s.Users.RoomID("bob")
+	carolRoom, _ := s.Users.RoomID("carol")
+	if bobRoom != carolRoom {
+		t.Error("carol did not join the room of bob in the same bracket")
+	}
+}
+
+func TestConnectWithInvalidLevel(t *testing.T) {
+	s := New()
+
+	res, err := s.ConnectWithLevel("alice", 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 400 {
+		t.Errorf("status code = %d, want 400", res.StatusCode)
+	}
+	if s.Open("alice") {
+		t.Error("alice is connected")
+	}
+}
+
+func TestWaitingTimeout(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+	roomID, _ := s.Users.RoomID("alice")
+
+	if err := s.Advance(join.DefaultWaitingTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "NO_OPPONENT")
+	if s.Open("alice") {
+		t.Error("alice is still connected")
+	}
+	if _, err := s.Users.RoomID("alice"); err == nil {
+		t.Error("alice is not deleted")
+	}
+	if _, err := s.Rooms.Get(roomID); err == nil {
+		t.Error("the room is not deleted")
+	}
+	if s.Matchmaker.Len() != 0 {
+		t.Error("the room of alice is still in the queue")
+	}
+}
+
+func TestWaitingTimeoutAfterMatch(t *testing.T) {
+	s := New()
+	startGame(t, s, "alice", "bob")
+
+	if err := s.Advance(join.DefaultWaitingTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME")
+	if !s.Open("alice") {
+		t.Error("alice is disconnected")
+	}
+}
+
+func TestTimeUp(t *testing.T) {
+	s := New()
+	problem := startGame(t, s, "alice", "bob")
+	roomID, _ := s.Users.RoomID("alice")
+
+	var frame struct {
+		TimeLimit int `json:"timeLimit"`
+	}
+	s.LastFrame("alice", &frame)
+	if frame.TimeLimit <= 0 {
+		t.Fatalf("time limit = %d, want positive", frame.TimeLimit)
+	}
+
+	if err := s.Advance(time.Duration(frame.TimeLimit) * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.Solve("alice", answerFor(t, problem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 400 {
+		t.Errorf("status code = %d, want 400", res.StatusCode)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME", "TIME_UP", "GAME_OVER")
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME", "TIME_UP")
+	room, _ := s.Rooms.Get(roomID)
+	if room.Status != "FINISHED" || room.WinnerID != "" || room.EndReason != "TIME_UP" {
+		t.Errorf("room = %+v, want a draw by time up", room)
+	}
+}
+
+func TestSolveAfterTimeLimit(t *testing.T) {
+	s := New()
+	s.HoldJobs = true
+	problem := startGame(t, s, "alice", "bob")
+	roomID, _ := s.Users.RoomID("alice")
+
+	var frame struct {
+		TimeLimit int `json:"timeLimit"`
+	}
+	s.LastFrame("alice", &frame)
+
+	// the time up job is due but has not run yet
+	if err := s.Advance(time.Duration(frame.TimeLimit) * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.Solve("alice", answerFor(t, problem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 400 {
+		t.Errorf("status code = %d, want 400", res.StatusCode)
+	}
+	room, _ := s.Rooms.Get(roomID)
+	if room.Status != "PLAYING" || room.WinnerID != "" {
+		t.Errorf("room = %+v, want the game still playing", room)
+	}
+
+	if err := s.RunJobs(); err != nil {
+		t.Fatal(err)
+	}
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME", "GAME_OVER", "TIME_UP")
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME", "TIME_UP")
+	room, _ = s.Rooms.Get(roomID)
+	if room.Status != "FINISHED" || room.WinnerID != "" || room.EndReason != "TIME_UP" {
+		t.Errorf("room = %+v, want a draw by time up", room)
+	}
+}
+
+func TestSolveBeforeTimeUp(t *testing.T) {
+	s := New()
+	problem := startGame(t, s, "alice", "bob")
+
+	s.Solve("bob", answerFor(t, problem))
+	if err := s.Advance(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME", "YOU_LOSE")
+	assertMessages(t, s, "bob", "MATCHED", "START_GAME", "YOU_WIN")
+}
+
+func TestSolveWithTimesAndDivide(t *testing.T) {
+	s := New()
+	s.Connect("alice")
+	s.Connect("bob")
+	s.Ready("alice", 9)
+	s.Ready("bob", 9)
+
+	var frame struct {
+		Problem []int     `json:"problem"`
+		Spec    game.Spec `json:"spec"`
+	}
+	if err := s.LastFrame("alice", &frame); err != nil {
+		t.Fatal(err)
+	}
+	if !frame.Spec.Allows("x") || !frame.Spec.Allows("d") {
+		t.Fatalf("spec %+v does not allow times and divide", frame.Spec)
+	}
+
+	if _, err := s.Solve("alice", answerForSpec(t, frame.Spec, frame.Problem)); err != nil {
+		t.Fatal(err)
+	}
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME", "YOU_WIN")
+}
+
+func TestRejectDisabledOperator(t *testing.T) {
+	s := New()
+	startGame(t, s, "alice", "bob")
+
+	if _, err := s.Solve("alice", []string{"x", "x", "x", "x"}); err != nil {
+		t.Fatal(err)
+	}
+	assertMessages(t, s, "alice", "MATCHED", "START_GAME", "WRONG_ANSWER")
+}
+
+// dailyChallenge connects the player for the daily challenge
+// and returns the problem of the day
+func dailyChallenge(t *testing.T, s *Simulator, player string) ([]int, game.Spec) {
+	t.Helper()
+
+	if _, err := s.ConnectDaily(player); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Daily(player, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var frame struct {
+		Message string    `json:"message"`
+		Problem []int     `json:"problem"`
+		Spec    game.Spec `json:"spec"`
+		Date    string    `json:"date"`
+	}
+	if err := s.LastFrame(player, &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Message != "DAILY_CHALLENGE" || frame.Date != game.DailyDate(s.Scheduler.Now()) {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	return frame.Problem, frame.Spec
+}
+
+func TestDailyChallenge(t *testing.T) {
+	s := New()
+	problem, spec := dailyChallenge(t, s, "alice")
+	bobProblem, _ := dailyChallenge(t, s, "bob")
+	if !reflect.DeepEqual(bobProblem, problem) {
+		t.Fatalf("problem of bob = %v, want %v", bobProblem, problem)
+	}
+	if roomID, _ := s.Users.RoomID("alice"); roomID != "" {
+		t.Errorf("alice is put into room %s", roomID)
+	}
+	answer := answerForSpec(t, spec, problem)
+
+	var frame struct {
+		Message   string `json:"message"`
+		Rank      int    `json:"rank"`
+		SolveTime int64  `json:"solveTime"`
+	}
+	s.Advance(5 * time.Second)
+	s.Daily("bob", answer)
+	if err := s.LastFrame("bob", &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Message != "DAILY_SOLVED" || frame.Rank != 1 || frame.SolveTime != 5000 {
+		t.Errorf("frame to bob = %+v, want DAILY_SOLVED at rank 1 in 5000ms", frame)
+	}
+
+	s.Daily("alice", []string{})
+	s.Advance(3 * time.Second)
+	s.Daily("alice", answer)
+	if err := s.LastFrame("alice", &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Message != "DAILY_SOLVED" || frame.Rank != 2 || frame.SolveTime != 8000 {
+		t.Errorf("frame to alice = %+v, want DAILY_SOLVED at rank 2 in 8000ms", frame)
+	}
+
+	res, err := s.Daily("alice", answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 400 {
+		t.Errorf("status code = %d, want 400", res.StatusCode)
+	}
+	assertMessages(t, s, "alice", "DAILY_CHALLENGE", "WRONG_ANSWER", "DAILY_SOLVED", "ALREADY_SOLVED")
+}
+
+func TestDailyProblemChangesByDay(t *testing.T) {
+	s := New()
+	today, _ := dailyChallenge(t, s, "alice")
+
+	s.Advance(24 * time.Hour)
+	tomorrow, _ := dailyChallenge(t, s, "bob")
+
+	if reflect.DeepEqual(today, tomorrow) {
+		t.Errorf("problem of tomorrow = %v, the same as today", tomorrow)
+	}
+}
+
+func TestDailyAnswerWithoutChallenge(t *testing.T) {
+	s := New()
+	problem, spec := dailyChallenge(t, s, "alice")
+	s.ConnectDaily("bob")
+
+	res, err := s.Daily("bob", answerForSpec(t, spec, problem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 400 {
+		t.Errorf("status code = %d, want 400", res.StatusCode)
+	}
+	assertMessages(t, s, "bob", "GAME_NOT_STARTED")
+}
+
+func TestDailyResultsByPlayer(t *testing.T) {
+	s := New()
+	if _, err := s.ConnectDailyAs("conn1", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	s.Daily("conn1", nil)
+	var challenge struct {
+		Problem []int     `json:"problem"`
+		Spec    game.Spec `json:"spec"`
+	}
+	if err := s.LastFrame("conn1", &challenge); err != nil {
+		t.Fatal(err)
+	}
+	answer := answerForSpec(t, challenge.Spec, challenge.Problem)
+	s.Disconnect("conn1")
+
+	// the clock of alice keeps running on the new connection
+	s.Advance(10 * time.Second)
+	s.ConnectDailyAs("conn2", "alice")
+	s.Daily("conn2", nil)
+	s.Advance(2 * time.Second)
+	s.Daily("conn2", answer)
+
+	var frame struct {
+		Message   string `json:"message"`
+		SolveTime int64  `json:"solveTime"`
+	}
+	if err := s.LastFrame("conn2", &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Message != "DAILY_SOLVED" || frame.SolveTime != 12000 {
+		t.Errorf("frame to conn2 = %+v, want DAILY_SOLVED in 12000ms", frame)
+	}
+
+	s.ConnectDailyAs("conn3", "alice")
+	s.Daily("conn3", nil)
+	s.Daily("conn3", answer)
+	assertMessages(t, s, "conn3", "DAILY_CHALLENGE", "ALREADY_SOLVED")
+}
+
+func TestDailyAcrossMidnight(t *testing.T) {
+	s := New()
+	s.Advance(24*time.Hour - 10*time.Second)
+	problem, spec := dailyChallenge(t, s, "alice")
+	date := game.DailyDate(s.Scheduler.Now())
+
+	s.Advance(20 * time.Second)
+	s.Daily("alice", answerForSpec(t, spec, problem))
+
+	var frame struct {
+		Message   string `json:"message"`
+		Date      string `json:"date"`
+		SolveTime int64  `json:"solveTime"`
+	}
+	if err := s.LastFrame("alice", &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Message != "DAILY_SOLVED" || frame.Date != date || frame.SolveTime != 20000 {
+		t.Errorf("frame to alice = %+v, want DAILY_SOLVED of %s in 20000ms", frame, date)
+	}
+}
+
+func TestNoMatchWithOwnRoom(t *testing.T) {
+	s := New()
+	s.ConnectAs("conn1", "alice")
+	s.ConnectAs("conn2", "alice")
+	room1, _ := s.Users.RoomID("conn1")
+	room2, _ := s.Users.RoomID("conn2")
+	if room1 == room2 {
+		t.Fatalf("both connections of alice are matched in %s", room1)
+	}
+
+	// the room of alice is still the first for the others
+	s.ConnectAs("conn3", "bob")
+	room3, _ := s.Users.RoomID("conn3")
+	if room3 != room1 {
+		t.Errorf("bob joined %s, want the first room %s", room3, room1)
+	}
+	assertMessages(t, s, "conn1", "MATCHED")
+	assertMessages(t, s, "conn3", "MATCHED")
+	assertMessages(t, s, "conn2")
+}
+
+// startRatedGame connects two players as the players of the same names
+// and gets both ready
+func startRatedGame(t *testing.T, s *Simulator, player1 string, player2 string) []int {
+	t.Helper()
+
+	for _, player := range []string{player1, player2} {
+		if _, err := s.ConnectAs(player, "player-"+player); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, player := range []string{player1, player2} {
+		if _, err := s.Ready(player, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var frame struct {
+		Message string `json:"message"`
+		Problem []int  `json:"problem"`
+	}
+	if err := s.LastFrame(player1, &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Message != "START_GAME" {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	return frame.Problem
+}
+
+func assertRating(t *testing.T, s *Simulator, connectionID string, message string, rating int, change int) {
+	t.Helper()
+
+	var frame struct {
+		Message      string `json:"message"`
+		Rating       int    `json:"rating"`
+		RatingChange int    `json:"ratingChange"`
+	}
+	if err := s.LastFrame(connectionID, &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Message != message || frame.Rating != rating || frame.RatingChange != change {
+		t.Errorf("frame to %s = %+v, want %s with rating %d (%+d)",
+			connectionID, frame, message, rating, change)
+	}
+}
+
+func TestRatingAfterWin(t *testing.T) {
+	s := New()
+	problem := startRatedGame(t, s, "alice", "bob")
+	s.Solve("alice", answerFor(t, problem))
+
+	assertRating(t, s, "alice", "YOU_WIN", 1516, 16)
+	assertRating(t, s, "bob", "YOU_LOSE", 1484, -16)
+
+	// the ratings survive the connections
+	s.Disconnect("alice")
+	s.ConnectAs("carol", "player-alice")
+	s.ConnectAs("dave", "player-bob")
+	s.Ready("carol", 0)
+	s.Ready("dave", 0)
+	var frame struct {
+		Problem []int `json:"problem"`
+	}
+	if err := s.LastFrame("carol", &frame); err != nil {
+		t.Fatal(err)
+	}
+	s.Solve("carol", answerFor(t, frame.Problem))
+
+	// the expected winner gains less
+	assertRating(t, s, "carol", "YOU_WIN", 1531, 15)
+	assertRating(t, s, "dave", "YOU_LOSE", 1469, -15)
+}
+
+func TestNoRatingForAnonymous(t *testing.T) {
+	s := New()
+	problem := startGame(t, s, "alice", "bob")
+	s.Solve("alice", answerFor(t, problem))
+
+	assertRating(t, s, "alice", "YOU_WIN", 0, 0)
+	assertRating(t, s, "bob", "YOU_LOSE", 0, 0)
+	for _, connectionID := range []string{"alice", "bob"} {
+		if _, err := s.Players.Get(connectionID); err != players.ErrPlayerNotFound {
+			t.Errorf("the rating of %s is stored, err = %v", connectionID, err)
+		}
+	}
+}
+
+func TestRatingAfterForfeit(t *testing.T) {
+	s := New()
+	startRatedGame(t, s, "alice", "bob")
+	s.Disconnect("bob")
+
+	assertRating(t, s, "alice", "YOU_WIN", 1516, 16)
+	bob, err := s.Players.Get("player-bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Rating != 1484 || bob.Games != 1 {
+		t.Errorf("bob = %+v, want rating 1484 after 1 game", bob)
+	}
+}
+
+func TestLeaveAfterTimeLimit(t *testing.T) {
+	s := New()
+	s.HoldJobs = true
+	startRatedGame(t, s, "alice", "bob")
+	roomID, _ := s.Users.RoomID("alice")
+
+	// bob leaves before the time up job runs
+	s.Advance(game.TimeLimit(ready.DefaultLevel))
+	s.Disconnect("bob")
+
+	assertRating(t, s, "alice", "TIME_UP", 1500, 0)
+	room, _ := s.Rooms.Get(roomID)
+	if room.Status != "FINISHED" || room.WinnerID != "" || room.EndReason != "TIME_UP" {
+		t.Errorf("room = %+v, want a draw by time up", room)
+	}
+}
+
+func TestRatingAfterDraw(t *testing.T) {
+	s := New()
+	startRatedGame(t, s, "alice", "bob")
+
+	if err := s.Advance(game.TimeLimit(ready.DefaultLevel)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, player := range []string{"alice", "bob"} {
+		assertRating(t, s, player, "TIME_UP", 1500, 0)
+		p, _ := s.Players.Get("player-" + player)
+		if p.Games != 1 {
+			t.Errorf("%s has played %d games, want 1", player, p.Games)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package solver
+
+// the operators of the answer
+const (
+	OpPlus   string = "p"
+	OpMinus  string = "m"
+	OpTimes  string = "x"
+	OpDivide string = "d"
+)
+
+// Rule is the order the operators are evaluated in
+type Rule string
+
+const (
+	// RuleLeftToRight evaluates every operator from left to right
+	RuleLeftToRight Rule = "LEFT_TO_RIGHT"
+	// RulePrecedence evaluates times and divide before plus and minus
+	RulePrecedence Rule = "PRECEDENCE"
+)
+
+// Problem is the terms and what the operators have to make of them
+type Problem struct {
+	Terms     []int
+	Target    int
+	Operators []string
+	Rule      Rule
+}
+
+// Apply returns x op y.
+// ok is false when the division is by zero or leaves a remainder.
+func Apply(op string, x int, y int) (int, bool) {
+	switch op {
+	case OpPlus:
+		return x + y, true
+	case OpMinus:
+		return x - y, true
+	case OpTimes:
+		return x * y, true
+	case OpDivide:
+		if y == 0 || x%y != 0 {
+			return 0, false
+		}
+		return x / y, true
+	}
+	return 0, false
+}
+
+// state is the value of the expression read so far.
+// group is the run of times and divide which is not added to sum yet.
+// Under RuleLeftToRight every operator applies to group.
+type state struct {
+	sum   int
+	sign  int
+	group int
+}
+
+func (st state) value() int {
+	return st.sum + st.sign*st.group
+}
+
+func step(rule Rule, st state, op string, term int) (state, bool) {
+	if rule == RulePrecedence && (op == OpPlus || op == OpMinus) {
+		sign := 1
+		if op == OpMinus {
+			sign = -1
+		}
+		return state{sum: st.value(), sign: sign, group: term}, true
+	}
+
+	group, ok := Apply(op, st.group, term)
+	return state{sum: st.sum, sign: st.sign, group: group}, ok
+}
+
+// Evaluate returns the value the operators make from the terms under the rule.
+// ok is false when the expression is not valid.
+func Evaluate(rule Rule, terms []int, ops []string) (int, bool) {
+	if len(terms) == 0 || len(terms) != len(ops)+1 {
+		return 0, false
+	}
+
+	st := state{sign: 1, group: terms[0]}
+	for i, op := range ops {
+		var ok bool
+		st, ok = step(rule, st, op, terms[i+1])
+		if !ok {
+			return 0, false
+		}
+	}
+	return st.value(), true
+}
+
+type key struct {
+	index int
+	state state
+}
+
+// solver counts the solutions of the rest of the terms from every state.
+// The counts are memoized, so the states which lead nowhere are pruned.
+type solver struct {
+	problem Problem
+	counts  map[key]int
+}
+
+func newSolver(p Problem) *solver {
+	return &solver{problem: p, counts: map[key]int{}}
+}
+
+// count returns the number of the operators for the terms after index
+// which make the target from the state
+func (s *solver) count(index int, st state) int {
+	terms := s.problem.Terms
+	if index == len(terms)-1 {
+		if st.value() == s.problem.Target {
+			return 1
+		}
+		return 0
+	}
+
+	k := key{index: index, state: st}
+	if n, ok := s.counts[k]; ok {
+		return n
+	}
+
+	n := 0
+	for _, op := range s.problem.Operators {
+		next, ok := step(s.problem.Rule, st, op, terms[index+1])
+		if ok {
+			n += s.count(index+1, next)
+		}
+	}
+	s.counts[k] = n
+	return n
+}
+
+// collect appends every solution from the state to solutions
+func (s *solver) collect(index int, st state, ops []string, solutions [][]string) [][]string {
+	terms := s.problem.Terms
+	if index == len(terms)-1 {
+		return append(solutions, append([]string(nil), ops...))
+	}
+
+	for _, op := range s.problem.Operators {
+		next, ok := step(s.problem.Rule, st, op, terms[index+1])
+		if !ok || s.count(index+1, next) == 0 {
+			continue
+		}
+		solutions = s.collect(index+1, next, append(ops, op), solutions)
+	}
+	return solutions
+}
+
+func (s *solver) start() state {
+	return state{sign: 1, group: s.problem.Terms[0]}
+}
+
+// fewest returns the fewest uses of the operator in the solutions
+// for the terms after index from the state.
+// ok is false when there is no solution.
+func (s *solver) fewest(index int, st state, op string, memo map[key]int) (int, bool) {
+	k := key{index: index, state: st}
+	if s.count(index, st) == 0 {
+		return 0, false
+	}
+	if index == len(s.problem.Terms)-1 {
+		return 0, true
+	}
+	if n, ok := memo[k]; ok {
+		return n, true
+	}
+
+	best, found := 0, false
+	for _, o := range s.problem.Operators {
+		next, ok := step(s.problem.Rule, st, o, s.problem.Terms[index+1])
+		if !ok {
+			continue
+		}
+		n, ok := s.fewest(index+1, next, op, memo)
+		if !ok {
+			continue
+		}
+		if o == op {
+			n++
+		}
+		if !found || n < best {
+			best, found = n, true
+		}
+	}
+	memo[k] = best
+	return best, found
+}
+
+// Count returns the number of the solutions of the problem
+func Count(p Problem) int {
+	if len(p.Terms) == 0 {
+		return 0
+	}
+
+	s := newSolver(p)
+	return s.count(0, s.start())
+}
+
+// Solvable returns whether the problem has a solution
+func Solvable(p Problem) bool {
+	return Count(p) > 0
+}
+
+// Solve returns every solution of the problem
+func Solve(p Problem) [][]string {
+	if len(p.Terms) == 0 {
+		return nil
+	}
+
+	s := newSolver(p)
+	st := s.start()
+	if s.count(0, st) == 0 {
+		return nil
+	}
+	return s.collect(0, st, make([]string, 0, len(p.Terms)-1), nil)
+}
+
+// Fewest returns the fewest uses of the operator among the solutions of the problem.
+// ok is false when the problem has no solution.
+func Fewest(p Problem, op string) (int, bool) {
+	if len(p.Terms) == 0 {
+		return 0, false
+	}
+
+	s := newSolver(p)
+	return s.fewest(0, s.start(), op, map[key]int{})
+}
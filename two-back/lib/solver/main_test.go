@@ -0,0 +1,124 @@
+package solver
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var allOperators = []string{OpPlus, OpMinus, OpTimes, OpDivide}
+
+// naiveCount evaluates every assignment of the operators
+func naiveCount(p Problem) int {
+	n := len(p.Terms) - 1
+	ops := make([]string, n)
+	count := 0
+	var try func(i int)
+	try = func(i int) {
+		if i == n {
+			if v, ok := Evaluate(p.Rule, p.Terms, ops); ok && v == p.Target {
+				count++
+			}
+			return
+		}
+		for _, op := range p.Operators {
+			ops[i] = op
+			try(i + 1)
+		}
+	}
+	try(0)
+	return count
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		rule  Rule
+		terms []int
+		ops   []string
+		want  int
+		ok    bool
+	}{
+		{RuleLeftToRight, []int{1, 2, 3}, []string{OpPlus, OpTimes}, 9, true},
+		{RulePrecedence, []int{1, 2, 3}, []string{OpPlus, OpTimes}, 7, true},
+		{RulePrecedence, []int{8, 6, 3, 1}, []string{OpMinus, OpDivide, OpMinus}, 5, true},
+		{RuleLeftToRight, []int{7, 2}, []string{OpDivide}, 0, false},
+		{RuleLeftToRight, []int{7, 0}, []string{OpDivide}, 0, false},
+		{RuleLeftToRight, []int{7}, []string{}, 7, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := Evaluate(tt.rule, tt.terms, tt.ops)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Evaluate(%s, %v, %v) = %d, %v, want %d, %v",
+				tt.rule, tt.terms, tt.ops, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCountMatchesEnumeration(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		terms := make([]int, 1+r.Intn(7))
+		for j := range terms {
+			terms[j] = r.Intn(10)
+		}
+		for _, rule := range []Rule{RuleLeftToRight, RulePrecedence} {
+			p := Problem{Terms: terms, Target: 2, Operators: allOperators, Rule: rule}
+
+			want := naiveCount(p)
+			if got := Count(p); got != want {
+				t.Fatalf("Count(%+v) = %d, want %d", p, got, want)
+			}
+
+			solutions := Solve(p)
+			if len(solutions) != want {
+				t.Fatalf("Solve(%+v) has %d solutions, want %d", p, len(solutions), want)
+			}
+			for _, ops := range solutions {
+				if v, ok := Evaluate(rule, terms, ops); !ok || v != p.Target {
+					t.Fatalf("solution %v of %+v makes %d", ops, p, v)
+				}
+			}
+		}
+	}
+}
+
+func TestSolveManyTerms(t *testing.T) {
+	terms := make([]int, 40)
+	for i := range terms {
+		terms[i] = i%9 + 1
+	}
+	p := Problem{Terms: terms, Target: 2, Operators: []string{OpPlus, OpMinus}, Rule: RuleLeftToRight}
+
+	if !Solvable(p) {
+		t.Errorf("%v has no solution", terms)
+	}
+}
+
+func TestFewestMatchesEnumeration(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		terms := make([]int, 1+r.Intn(7))
+		for j := range terms {
+			terms[j] = r.Intn(10)
+		}
+		p := Problem{Terms: terms, Target: 2, Operators: allOperators, Rule: RulePrecedence}
+
+		want, found := 0, false
+		for _, ops := range Solve(p) {
+			n := 0
+			for _, op := range ops {
+				if op == OpMinus {
+					n++
+				}
+			}
+			if !found || n < want {
+				want, found = n, true
+			}
+		}
+
+		got, ok := Fewest(p, OpMinus)
+		if got != want || ok != found {
+			t.Fatalf("Fewest(%+v) = %d, %v, want %d, %v", p, got, ok, want, found)
+		}
+	}
+}
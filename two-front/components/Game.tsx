@@ -4,16 +4,17 @@ import Number from "./Number";
 import styles from "../styles/Game.module.css";
 
 const EQUAL_SYMBOL = "=";
-const ANSWER = 2;
 
 interface Props {
   problem: number[];
+  target: number;
+  operators: MARK[];
   answer: MARK[];
   onChange: (answer: MARK, i: number) => void;
 }
 
 const Game: React.FC<Props> = (props: Props) => {
-  const { problem, answer, onChange } = props;
+  const { problem, target, operators, answer, onChange } = props;
 
   const handleChange = (s: MARK, i: number) => {
     onChange(s, i);
@@ -28,6 +29,7 @@ const Game: React.FC<Props> = (props: Props) => {
             <MarkInput
               index={i}
               initValue={v}
+              operators={operators}
               onChange={handleChange}
             />
             <Number number={problem[i + 1]} />
@@ -36,7 +38,7 @@ const Game: React.FC<Props> = (props: Props) => {
       })}
       <div className={styles.flexrow}>
         <div className={styles.equal}>{EQUAL_SYMBOL}</div>
-        <Number number={ANSWER} />
+        <Number number={target} />
       </div>
     </>
   );
@@ -3,17 +3,27 @@ import styles from "../styles/Mark.module.css";
 
 const PLUS = "p";
 const MINUS = "m";
+const TIMES = "x";
+const DIVIDE = "d";
 
-export type MARK = "p" | "m";
+export type MARK = "p" | "m" | "x" | "d";
+
+const SYMBOLS: { [mark in MARK]: string } = {
+  [PLUS]: "+",
+  [MINUS]: "-",
+  [TIMES]: "×",
+  [DIVIDE]: "÷",
+};
 
 interface Props {
   index: number;
   initValue: MARK;
+  operators: MARK[];
   onChange: (s: string, i: number) => void;
 }
 
 const MarkInput: React.FC<Props> = (props: Props) => {
-  const { index, initValue, onChange } = props;
+  const { index, initValue, operators, onChange } = props;
   const [mark, setMark] = useState(initValue);
 
   const handleChange = (e: React.ChangeEvent<HTMLInputElement>) => {
@@ -23,26 +33,20 @@ const MarkInput: React.FC<Props> = (props: Props) => {
 
   return (
     <div className={styles.flexcol}>
-      <label className={`${styles.mark} ${mark === PLUS ? styles.checked : ""}`}>
-        +
-        <input
-          type="radio"
-          name="mark"
-          value={PLUS}
-          checked={mark === PLUS}
-          onChange={handleChange}
-        />
-      </label>
-      <label className={`${styles.mark} ${mark === MINUS ? styles.checked : ""}`}>
-        -
-        <input
-          type="radio"
-          name="mark"
-          value={MINUS}
-          checked={mark === MINUS}
-          onChange={handleChange}
-        />
-      </label>
+      {operators.map((op) => {
+        return (
+          <label key={op} className={`${styles.mark} ${mark === op ? styles.checked : ""}`}>
+            {SYMBOLS[op]}
+            <input
+              type="radio"
+              name="mark"
+              value={op}
+              checked={mark === op}
+              onChange={handleChange}
+            />
+          </label>
+        );
+      })}
     </div>
   );
 };
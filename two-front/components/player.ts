@@ -0,0 +1,10 @@
+// playerID returns the id the rating and the daily results are kept by,
+// which is made on the first visit
+export function playerID(): string {
+  let id = localStorage.getItem("playerID");
+  if (!id) {
+    id = Math.random().toString(36).slice(2) + Date.now().toString(36);
+    localStorage.setItem("playerID", id);
+  }
+  return id;
+}
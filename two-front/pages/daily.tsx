@@ -0,0 +1,204 @@
+import React from "react";
+import Head from "next/head";
+import Button from "@material-ui/core/Button";
+import Snackbar from "@material-ui/core/Snackbar";
+import Alert from "@material-ui/lab/Alert";
+import { MARK } from "../components/MarkInput";
+import Game from "../components/Game";
+import { playerID } from "../components/player";
+import styles from "../styles/Home.module.css";
+
+const apiEndpoint = process.env.NEXT_PUBLIC_WS_ENDPOINT;
+
+interface State {
+  message: string;
+  openSnackBar: boolean;
+  isPlaying: boolean;
+  problem: number[];
+  target: number;
+  operators: MARK[];
+  answer: MARK[];
+}
+
+class Daily extends React.Component<{}, State> {
+  socket: WebSocket;
+
+  constructor(props: {}) {
+    super(props);
+    this.state = {
+      message: "Please waiting...",
+      openSnackBar: true,
+      isPlaying: false,
+      problem: [],
+      target: 2,
+      operators: ["p", "m"],
+      answer: [],
+    };
+  }
+
+  componentDidMount() {
+    this.socket = new WebSocket(`${apiEndpoint}?mode=daily&player=${playerID()}`);
+    this.socket.onopen = this.challenge.bind(this);
+    this.socket.onmessage = this.handleMessage.bind(this);
+    this.socket.onclose = this.onDisconnect.bind(this);
+  }
+
+  challenge() {
+    const data = {
+      "action": "daily",
+    };
+    this.socket.send(JSON.stringify(data));
+  }
+
+  onDisconnect() {
+    this.setState({
+      message: "This is disconnected.",
+    });
+    this.openSnackbar();
+  }
+
+  handleMessage(ev: MessageEvent): void {
+    const data = JSON.parse(ev.data);
+
+    if (!data.message) {
+      new Error("Unexpected response");
+    }
+
+    switch (data.message) {
+      case "DAILY_CHALLENGE":
+        this.startGame(data.date, data.problem, data.spec.target, data.spec.operators);
+        break;
+      case "WRONG_ANSWER":
+        this.isWrongAnswer();
+        break;
+      case "DAILY_SOLVED":
+        this.solved(data.rank, data.solveTime);
+        break;
+      case "ALREADY_SOLVED":
+        this.alreadySolved();
+        break;
+      default:
+        new Error("Unexpected response");
+    }
+  }
+
+  startGame(date: string, problem: number[], target: number, operators: MARK[]) {
+    this.setState({
+      message: `The challenge of ${date} !!!`,
+      isPlaying: true,
+      problem: problem,
+      target: target,
+      operators: operators,
+      answer: Array(problem.length - 1).fill("p"),
+    });
+    this.openSnackbar();
+  }
+
+  isWrongAnswer() {
+    this.setState({
+      message: "Your answer is wrong :-(",
+    });
+    this.openSnackbar();
+  }
+
+  solved(rank: number, solveTime: number) {
+    this.setState({
+      message: `Solved in ${(solveTime / 1000).toFixed(1)} seconds. You are #${rank} today!!!`,
+    });
+    this.openSnackbar();
+  }
+
+  alreadySolved() {
+    this.setState({
+      message: "You have already solved today's challenge.",
+    });
+    this.openSnackbar();
+  }
+
+  onChange(s: MARK, i: number) {
+    const { answer } = this.state;
+    answer[i] = s;
+    this.setState({
+      answer: answer,
+    });
+  }
+
+  sendAnswer() {
+    const { answer } = this.state;
+    const data = {
+      "action": "daily",
+      "answer": answer,
+    };
+    this.socket.send(JSON.stringify(data));
+  }
+
+  openSnackbar() {
+    this.setState({
+      openSnackBar: true,
+    });
+  }
+
+  closeSnackbar() {
+    this.setState({
+      openSnackBar: false,
+    });
+  }
+
+  render() {
+    const { message, openSnackBar, isPlaying, problem, target, operators, answer } = this.state;
+    return (
+      <div className={styles.container}>
+        <Head>
+          <title>Two Apps - Daily Challenge</title>
+          <link rel="icon" href="/favicon.ico" />
+        </Head>
+
+        <main className={styles.main}>
+          {/* Title */}
+          <h1 className={styles.title}>2</h1>
+          <p className={styles.description}>Make 2 faster than everyone today !</p>
+
+          {/* Game */}
+          <div className={styles.grid}>
+            {isPlaying
+              ? <Game
+                  problem={problem}
+                  target={target}
+                  operators={operators}
+                  answer={answer}
+                  onChange={this.onChange.bind(this)}
+                />
+              : <div className="loader">Loading...</div>
+            }
+          </div>
+          {isPlaying &&
+            <Button
+              variant="contained"
+              color="primary"
+              size="large"
+              onClick={this.sendAnswer.bind(this)}
+            >
+              Answer
+            </Button>
+          }
+        </main>
+
+        {/* message */}
+        <Snackbar open={openSnackBar} onClose={this.closeSnackbar.bind(this)}>
+          <Alert
+            elevation={6}
+            variant="filled"
+            severity="info"
+            onClose={this.closeSnackbar.bind(this)}
+          >
+            {message}
+          </Alert>
+        </Snackbar>
+
+        <footer className={styles.footer}>© 2020 uu64</footer>
+      </div>
+    );
+  }
+}
+
+export default Daily;
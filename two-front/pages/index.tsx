@@ -5,16 +5,26 @@ import Snackbar from "@material-ui/core/Snackbar";
 import Alert from "@material-ui/lab/Alert";
 import { MARK } from "../components/MarkInput";
 import Game from "../components/Game";
+import { playerID } from "../components/player";
 import styles from "../styles/Home.module.css";
 
 const apiEndpoint = process.env.NEXT_PUBLIC_WS_ENDPOINT;
-const level = 5;
+
+// ratingMessage tells the new rating and its change
+function ratingMessage(rating: number, change: number): string {
+  if (!rating) {
+    return "";
+  }
+  return `Your rating is ${rating} (${change >= 0 ? "+" : ""}${change}).`;
+}
 
 interface State {
   message: string;
   openSnackBar: boolean;
   isPlaying: boolean;
   problem: number[];
+  target: number;
+  operators: MARK[];
   answer: MARK[];
 }
 
@@ -28,41 +38,28 @@ class Home extends React.Component<{}, State> {
       openSnackBar: true,
       isPlaying: false,
       problem: [],
+      target: 2,
+      operators: ["p", "m"],
       answer: [],
     };
   }
 
   componentDidMount() {
-    this.socket = new WebSocket(apiEndpoint);
-    this.socket.onopen = () => {
-      setTimeout(() => {
-        this.startMatching(level);
-      }, 3000);
-    };
+    this.socket = new WebSocket(`${apiEndpoint}?player=${playerID()}`);
+    this.socket.onopen = this.startMatching.bind(this);
     this.socket.onmessage = this.handleMessage.bind(this);
     this.socket.onclose = this.onDisconnect.bind(this);
   }
 
-  startMatching(level: number) {
-    const data = {
-      "action": "problem",
-      "level": level,
-    };
-    this.socket.send(JSON.stringify(data));
-    setTimeout(() => {
-      this.hasNoPlayer();
-    }, 60000);
+  startMatching() {
+    this.waiting();
   }
 
   hasNoPlayer() {
-    const { isPlaying } = this.state;
-    if (!isPlaying) {
-      this.setState({
-        message:
-          "There is no player. This is disconnected after 3 seconds ...",
-      });
-      this.disconnect();
-    }
+    this.setState({
+      message: "There is no player.",
+    });
+    this.openSnackbar();
   }
 
   onDisconnect() {
@@ -83,17 +80,38 @@ class Home extends React.Component<{}, State> {
       case "PLEASE_WAIT":
         this.waiting();
         break;
+      case "NO_OPPONENT":
+        this.hasNoPlayer();
+        break;
+      case "MATCHED":
+        this.matched();
+        break;
+      case "MATCH_CANCELLED":
+        this.waiting();
+        break;
+      case "READY_TIMEOUT":
+        this.readyTimeout();
+        break;
+      case "NO_PROBLEM":
+        this.noProblem();
+        break;
       case "START_GAME":
-        this.startGame(data.problem);
+        this.startGame(data.problem, data.spec.target, data.spec.operators);
         break;
       case "WRONG_ANSWER":
         this.isWrongAnswer();
         break;
       case "YOU_WIN":
-        this.win();
+        this.win(data.rating, data.ratingChange || 0);
         break;
       case "YOU_LOSE":
-        this.lose();
+        this.lose(data.rating, data.ratingChange || 0);
+        break;
+      case "OPPONENT_LEFT":
+        this.opponentLeft();
+        break;
+      case "TIME_UP":
+        this.timeUp(data.rating, data.ratingChange || 0);
         break;
       default:
         new Error("Unexpected response");
@@ -107,11 +125,42 @@ class Home extends React.Component<{}, State> {
     this.openSnackbar();
   }
 
-  startGame(problem: number[]) {
+  matched() {
+    this.setState({
+      message: "A player is found !!!",
+    });
+    this.openSnackbar();
+    this.ready();
+  }
+
+  ready() {
+    const data = {
+      "action": "ready",
+    };
+    this.socket.send(JSON.stringify(data));
+  }
+
+  readyTimeout() {
+    this.setState({
+      message: "You were not ready in time.",
+    });
+    this.openSnackbar();
+  }
+
+  noProblem() {
+    this.setState({
+      message: "No problem was found for your level.",
+    });
+    this.openSnackbar();
+  }
+
+  startGame(problem: number[], target: number, operators: MARK[]) {
     this.setState({
       message: "Game start !!!",
       isPlaying: true,
       problem: problem,
+      target: target,
+      operators: operators,
       answer: Array(problem.length - 1).fill("p"),
     });
     this.openSnackbar();
@@ -124,22 +173,37 @@ class Home extends React.Component<{}, State> {
     this.openSnackbar();
   }
 
-  win() {
+  win(rating: number, change: number) {
+    this.setState({
+      message: `You win!!! ${ratingMessage(rating, change)} This is disconnected after 3 seconds ...`,
+    });
+    this.openSnackbar();
+    this.disconnect();
+  }
+
+  lose(rating: number, change: number) {
     this.setState({
-      message: "You win!!! This is disconnected after 3 seconds ...",
+      message: `You lose. ${ratingMessage(rating, change)} This is disconnected after 3 seconds ...`,
     });
     this.openSnackbar();
     this.disconnect();
   }
 
-  lose() {
+  timeUp(rating: number, change: number) {
     this.setState({
-      message: "You lose. This is disconnected after 3 seconds ...",
+      message: `Time is up. It's a draw. ${ratingMessage(rating, change)} This is disconnected after 3 seconds ...`,
     });
     this.openSnackbar();
     this.disconnect();
   }
 
+  opponentLeft() {
+    this.setState({
+      message: "Your opponent has left.",
+    });
+    this.openSnackbar();
+  }
+
   onChange(s: MARK, i: number) {
     const { answer } = this.state;
     answer[i] = s;
@@ -176,7 +240,7 @@ class Home extends React.Component<{}, State> {
   }
 
   render() {
-    const { message, openSnackBar, isPlaying, problem, answer } = this.state;
+    const { message, openSnackBar, isPlaying, problem, target, operators, answer } = this.state;
     return (
       <div className={styles.container}>
         <Head>
@@ -194,6 +258,8 @@ class Home extends React.Component<{}, State> {
             {isPlaying
               ? <Game
                   problem={problem}
+                  target={target}
+                  operators={operators}
                   answer={answer}
                   onChange={this.onChange.bind(this)}
                 />